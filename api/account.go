@@ -0,0 +1,150 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+	"github.com/takedown-observer/backend/models"
+	"gorm.io/gorm"
+)
+
+// GetAccountHandler handles GET /api/accounts/{id}
+func (h *Handler) GetAccountHandler(w http.ResponseWriter, r *http.Request) {
+	accountID := mux.Vars(r)["id"]
+
+	platform, err := parsePlatform(r.URL.Query())
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err)
+		return
+	}
+
+	var account models.Account
+	if err := h.db.First(&account, "platform = ? AND id = ?", platform, accountID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			WriteProblem(w, r, http.StatusNotFound, codeNotFound, "", "Account not found")
+			return
+		}
+		writeDatabaseError(w, r)
+		return
+	}
+
+	detail, err := loadAccountDetail(h.db, account)
+	if err != nil {
+		writeDatabaseError(w, r)
+		return
+	}
+
+	body, err := json.Marshal(detail)
+	if err != nil {
+		WriteProblem(w, r, http.StatusInternalServerError, codeInternalError, "", "Error encoding response")
+		return
+	}
+
+	// The ETag covers the whole representation, so it changes with any of
+	// the derived fields. ServeContent answers conditional requests.
+	sum := sha256.Sum256(body)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	http.ServeContent(w, r, "", account.LastReportedAt, bytes.NewReader(body))
+}
+
+// loadAccountDetail derives the reporter count, per-country observation
+// ranges and confidence, and name history of an account
+func loadAccountDetail(db *gorm.DB, account models.Account) (models.AccountDetail, error) {
+	accounts := []models.Account{account}
+	if err := loadPreviousNames(db, accounts); err != nil {
+		return models.AccountDetail{}, err
+	}
+
+	detail := models.AccountDetail{
+		Account:       accounts[0],
+		ReporterCount: account.ReportCount,
+		NameHistory:   []models.AccountName{},
+	}
+
+	err := db.Where("platform = ? AND account_id = ?", account.Platform, account.ID).
+		Order("first_seen_at asc, name asc").
+		Find(&detail.NameHistory).Error
+	if err != nil {
+		return detail, err
+	}
+
+	detail.CountryDetail, err = loadCountryDetail(db, account)
+	return detail, err
+}
+
+// loadCountryDetail returns every country an account has been observed
+// withheld in, ordered by country code. Ranges come from the account's
+// observations, widened by the country join table for accounts reported
+// before observations were recorded.
+func loadCountryDetail(db *gorm.DB, account models.Account) ([]models.AccountCountryDetail, error) {
+	var observed []struct {
+		CountryCode     string
+		FirstObservedAt sqlTime
+		LastObservedAt  sqlTime
+	}
+
+	result := db.Table("observations, json_each(observations.countries) AS country").
+		Select("country.value AS country_code, MIN(observations.observed_at) AS first_observed_at, MAX(observations.observed_at) AS last_observed_at").
+		Where("observations.platform = ? AND observations.account_id = ?", account.Platform, account.ID).
+		Group("country.value").
+		Scan(&observed)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	var current []models.AccountCountry
+	if err := db.Where("platform = ? AND account_id = ?", account.Platform, account.ID).Find(&current).Error; err != nil {
+		return nil, err
+	}
+
+	byCountry := make(map[string]*models.AccountCountryDetail)
+	for _, row := range observed {
+		byCountry[row.CountryCode] = &models.AccountCountryDetail{
+			Country:         row.CountryCode,
+			FirstObservedAt: row.FirstObservedAt.Time,
+			LastObservedAt:  row.LastObservedAt.Time,
+		}
+	}
+
+	for _, row := range current {
+		detail, ok := byCountry[row.CountryCode]
+		if !ok {
+			detail = &models.AccountCountryDetail{
+				Country:         row.CountryCode,
+				FirstObservedAt: row.FirstSeenAt,
+				LastObservedAt:  row.LastSeenAt,
+			}
+			byCountry[row.CountryCode] = detail
+		}
+		if row.FirstSeenAt.Before(detail.FirstObservedAt) {
+			detail.FirstObservedAt = row.FirstSeenAt
+		}
+		if row.LastSeenAt.After(detail.LastObservedAt) {
+			detail.LastObservedAt = row.LastSeenAt
+		}
+		detail.Withheld = true
+	}
+
+	reporters, err := countryReporters(db, []models.Account{account})
+	if err != nil {
+		return nil, err
+	}
+
+	countries := make([]models.AccountCountryDetail, 0, len(byCountry))
+	for _, detail := range byCountry {
+		detail.Reporters = reporters[keyOf(account)][detail.Country]
+		detail.Confidence = confidence(detail.Reporters, account.ReportCount)
+		countries = append(countries, *detail)
+	}
+	sort.Slice(countries, func(i, j int) bool {
+		return countries[i].Country < countries[j].Country
+	})
+
+	return countries, nil
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/takedown-observer/backend/models"
+)
+
+func getAccount(handler *Handler, accountID string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/api/accounts/"+accountID, nil)
+	req = mux.SetURLVars(req, map[string]string{"id": accountID})
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	w := httptest.NewRecorder()
+	handler.GetAccountHandler(w, req)
+	return w
+}
+
+func TestGetAccountHandler(t *testing.T) {
+	db := newTestDB(t)
+	handler := NewHandler(db)
+
+	postReport(t, handler, "123e4567-e89b-12d3-a456-426614174000", "account1", "old_name", []string{"DE", "FR"})
+	postReport(t, handler, "223e4567-e89b-12d3-a456-426614174000", "account1", "new_name", []string{"DE"})
+	postReport(t, handler, "223e4567-e89b-12d3-a456-426614174000", "account1", "new_name", []string{"DE", "IN"})
+
+	t.Run("returns derived fields", func(t *testing.T) {
+		w := getAccount(handler, "account1", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GetAccountHandler() status code = %v, want %v", w.Code, http.StatusOK)
+		}
+
+		var detail models.AccountDetail
+		if err := json.NewDecoder(w.Body).Decode(&detail); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+
+		if detail.ID != "account1" || detail.Name != "new_name" {
+			t.Errorf("Unexpected account %s (%s)", detail.ID, detail.Name)
+		}
+		if detail.ReporterCount != 2 {
+			t.Errorf("Expected 2 distinct reporters, got %d", detail.ReporterCount)
+		}
+		if detail.FirstSeenAt.IsZero() || detail.FirstSeenAt.After(detail.LastReportedAt) {
+			t.Errorf("Unexpected first seen %v", detail.FirstSeenAt)
+		}
+		if !reflect.DeepEqual(detail.PreviousNames, []string{"old_name"}) {
+			t.Errorf("Expected previous names [old_name], got %v", detail.PreviousNames)
+		}
+
+		var names []string
+		for _, name := range detail.NameHistory {
+			names = append(names, name.Name)
+		}
+		if !reflect.DeepEqual(names, []string{"old_name", "new_name"}) {
+			t.Errorf("Expected name history [old_name new_name], got %v", names)
+		}
+
+		if len(detail.CountryDetail) != 3 {
+			t.Fatalf("Expected 3 countries, got %v", detail.CountryDetail)
+		}
+		withheld := map[string]bool{"DE": true, "FR": false, "IN": true}
+		for _, country := range detail.CountryDetail {
+			if country.Withheld != withheld[country.Country] {
+				t.Errorf("Country %s withheld = %v, want %v", country.Country, country.Withheld, withheld[country.Country])
+			}
+			if country.LastObservedAt.Before(country.FirstObservedAt) {
+				t.Errorf("Country %s has invalid range %v - %v", country.Country, country.FirstObservedAt, country.LastObservedAt)
+			}
+		}
+
+		de, fr := detail.CountryDetail[0], detail.CountryDetail[1]
+		if de.Country != "DE" || !de.FirstObservedAt.Equal(detail.FirstSeenAt) || !de.LastObservedAt.Equal(detail.LastReportedAt) {
+			t.Errorf("Unexpected DE detail %+v", de)
+		}
+		if fr.Country != "FR" || !fr.LastObservedAt.Equal(detail.FirstSeenAt) {
+			t.Errorf("Unexpected FR detail %+v", fr)
+		}
+		if de.Reporters != 2 || de.Confidence != 1 || fr.Reporters != 1 || fr.Confidence != 0.5 {
+			t.Errorf("Unexpected confirmations DE %d (%v), FR %d (%v)", de.Reporters, de.Confidence, fr.Reporters, fr.Confidence)
+		}
+	})
+
+	t.Run("supports conditional requests", func(t *testing.T) {
+		w := getAccount(handler, "account1", nil)
+		etag := w.Header().Get("ETag")
+		lastModified := w.Header().Get("Last-Modified")
+		if etag == "" || lastModified == "" {
+			t.Fatalf("Expected ETag and Last-Modified headers, got %q and %q", etag, lastModified)
+		}
+
+		w = getAccount(handler, "account1", http.Header{"If-None-Match": {etag}})
+		if w.Code != http.StatusNotModified {
+			t.Errorf("If-None-Match status code = %v, want %v", w.Code, http.StatusNotModified)
+		}
+
+		w = getAccount(handler, "account1", http.Header{"If-Modified-Since": {lastModified}})
+		if w.Code != http.StatusNotModified {
+			t.Errorf("If-Modified-Since status code = %v, want %v", w.Code, http.StatusNotModified)
+		}
+
+		postReport(t, handler, "323e4567-e89b-12d3-a456-426614174000", "account1", "new_name", []string{"DE"})
+
+		w = getAccount(handler, "account1", http.Header{"If-None-Match": {etag}})
+		if w.Code != http.StatusOK {
+			t.Errorf("Stale If-None-Match status code = %v, want %v", w.Code, http.StatusOK)
+		}
+		if w.Header().Get("ETag") == etag {
+			t.Errorf("Expected ETag to change after a new report")
+		}
+	})
+
+	t.Run("unknown account", func(t *testing.T) {
+		w := getAccount(handler, "missing", nil)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("GetAccountHandler() status code = %v, want %v", w.Code, http.StatusNotFound)
+		}
+	})
+}
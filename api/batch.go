@@ -0,0 +1,113 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/takedown-observer/backend/models"
+	"github.com/takedown-observer/backend/validation"
+	"gorm.io/gorm"
+)
+
+// BatchReportHandler handles POST /api/reports/batch
+func (h *Handler) BatchReportHandler(w http.ResponseWriter, r *http.Request) {
+	// The raw body is kept since signatures are computed over it
+	body, ok := ReadBody(w, r, h.MaxBodySize())
+	if !ok {
+		return
+	}
+
+	// Reports are decoded individually, since each names its own format
+	var reports []json.RawMessage
+	if err := json.Unmarshal(body, &reports); err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidBody, errInvalidBody)
+		return
+	}
+
+	if len(reports) == 0 {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, validation.Errorf("", validation.CodeRequired, "Batch cannot be empty"))
+		return
+	}
+
+	if len(reports) > h.config.MaxBatchSize {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, validation.Errorf("", validation.CodeTooMany, "Batch exceeds maximum of %d reports", h.config.MaxBatchSize))
+		return
+	}
+
+	// A single challenge covers the whole batch, at a difficulty scaled to
+	// the number of reports
+	if err := h.checkProofOfWork(r, len(reports)); err != nil {
+		writeError(w, r, http.StatusForbidden, codeProofOfWork, err)
+		return
+	}
+
+	// A batch is signed by a single client, so reports from other clients
+	// fail the signature check individually
+	signer, verifyErr := h.verifySignature(r, body)
+
+	results := make([]models.BatchItemResult, len(reports))
+
+	// Database transaction; each report is stored within its own savepoint
+	// so that a failing report does not discard the others
+	var countriesChanged bool
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		for i, item := range reports {
+			format, report, err := decodeReport(item)
+			results[i] = models.BatchItemResult{
+				Index:     i,
+				AccountID: report.Account.ID,
+				Status:    models.BatchStatusSuccess,
+			}
+			if err != nil {
+				failBatchItem(&results[i], codeInvalidRequest, err)
+				continue
+			}
+			setDeprecationHeaders(w, format)
+
+			sanitizedAccount, err := validateReport(report)
+			if err != nil {
+				failBatchItem(&results[i], codeInvalidRequest, err)
+				continue
+			}
+
+			if err := h.checkSigned(signer, verifyErr, report.ClientID); err != nil {
+				failBatchItem(&results[i], codeUnauthorized, err)
+				continue
+			}
+
+			var changed bool
+			err = tx.Transaction(func(tx *gorm.DB) error {
+				var err error
+				changed, err = recordReport(tx, h.hashClientID(report.ClientID), sanitizedAccount)
+				return err
+			})
+			if err != nil {
+				failBatchItem(&results[i], codeDatabaseError, errors.New("Database error"))
+				continue
+			}
+			countriesChanged = countriesChanged || changed
+		}
+		return nil
+	})
+	if err == nil && countriesChanged {
+		h.countries.invalidate()
+	}
+	h.challenges.record(len(reports))
+
+	if err != nil {
+		writeDatabaseError(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.BatchReportResponse{Results: results})
+}
+
+// failBatchItem marks a report in a batch as failed with the code and field
+// its error would have as a problem
+func failBatchItem(result *models.BatchItemResult, code string, err error) {
+	result.Status = models.BatchStatusError
+	result.Error = err.Error()
+	result.Code, result.Field = problemCode(err, code)
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/takedown-observer/backend/models"
+	"gorm.io/gorm"
+)
+
+func TestBatchReportHandler(t *testing.T) {
+	validReport := func(accountID string) models.ReportRequest {
+		return models.ReportRequest{
+			ClientID: "123e4567-e89b-12d3-a456-426614174000",
+			Account: models.ReportedAccount{
+				ID:        accountID,
+				Name:      "TestAccount",
+				Countries: []string{"US", "GB"},
+			},
+			DataFormatVersion: "1.0",
+		}
+	}
+
+	tests := []struct {
+		name          string
+		reports       []models.ReportRequest
+		maxBatchSize  int
+		expectedCode  int
+		expectedError string
+		checkResponse func(*testing.T, models.BatchReportResponse)
+		checkDB       func(*testing.T, *gorm.DB)
+	}{
+		{
+			name: "mixed valid and invalid reports",
+			reports: []models.ReportRequest{
+				validReport("account1"),
+				{
+					ClientID:          "invalid-uuid",
+					Account:           validReport("account2").Account,
+					DataFormatVersion: "1.0",
+				},
+				validReport("account3"),
+			},
+			maxBatchSize: 10,
+			expectedCode: http.StatusOK,
+			checkResponse: func(t *testing.T, response models.BatchReportResponse) {
+				if len(response.Results) != 3 {
+					t.Fatalf("Expected 3 results, got %d", len(response.Results))
+				}
+				expectedStatuses := []string{models.BatchStatusSuccess, models.BatchStatusError, models.BatchStatusSuccess}
+				for i, result := range response.Results {
+					if result.Index != i {
+						t.Errorf("Expected result index %d, got %d", i, result.Index)
+					}
+					if result.Status != expectedStatuses[i] {
+						t.Errorf("Expected result %d status %s, got %s", i, expectedStatuses[i], result.Status)
+					}
+				}
+				if response.Results[1].Error != "Invalid client ID format" {
+					t.Errorf("Expected invalid client ID error, got %q", response.Results[1].Error)
+				}
+				if response.Results[1].Code != "invalid_format" || response.Results[1].Field != "client_id" {
+					t.Errorf("Expected invalid_format on client_id, got %q on %q", response.Results[1].Code, response.Results[1].Field)
+				}
+			},
+			checkDB: func(t *testing.T, db *gorm.DB) {
+				var count int64
+				db.Model(&models.Account{}).Count(&count)
+				if count != 2 {
+					t.Errorf("Expected 2 stored accounts, got %d", count)
+				}
+			},
+		},
+		{
+			name:          "exceeds maximum batch size",
+			reports:       []models.ReportRequest{validReport("account1"), validReport("account2")},
+			maxBatchSize:  1,
+			expectedCode:  http.StatusBadRequest,
+			expectedError: "exceeds maximum of 1 reports",
+		},
+		{
+			name:          "empty batch",
+			reports:       []models.ReportRequest{},
+			maxBatchSize:  10,
+			expectedCode:  http.StatusBadRequest,
+			expectedError: "Batch cannot be empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+
+			config := DefaultConfig()
+			config.MaxBatchSize = tt.maxBatchSize
+			handler := NewHandlerWithConfig(db, config)
+
+			body, _ := json.Marshal(tt.reports)
+			req := httptest.NewRequest("POST", "/api/reports/batch", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			handler.BatchReportHandler(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("BatchReportHandler() status code = %v, want %v", w.Code, tt.expectedCode)
+			}
+
+			if tt.expectedError != "" {
+				if !bytes.Contains(w.Body.Bytes(), []byte(tt.expectedError)) {
+					t.Errorf("BatchReportHandler() error = %v, want %v", w.Body.String(), tt.expectedError)
+				}
+			}
+
+			if tt.checkResponse != nil {
+				var response models.BatchReportResponse
+				if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+					t.Fatalf("Failed to decode response: %v", err)
+				}
+				tt.checkResponse(t, response)
+			}
+
+			if tt.checkDB != nil {
+				tt.checkDB(t, db)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/bits"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/takedown-observer/backend/models"
+)
+
+// Headers carrying a solved challenge
+const (
+	challengeHeader         = "X-Challenge"
+	challengeSolutionHeader = "X-Challenge-Solution"
+)
+
+// Size of challenge nonces in bytes
+const challengeNonceSize = 16
+
+// challengeIssuer issues and verifies hashcash-style challenges. A challenge
+// is a server-signed token naming a nonce, difficulty and expiry; it is
+// solved by finding a string whose SHA-256 hash together with the token
+// starts with the given number of zero bits. Used nonces are remembered
+// until the challenge expires so each challenge is accepted once.
+type challengeIssuer struct {
+	secret        []byte
+	ttl           time.Duration
+	minDifficulty int
+	maxDifficulty int
+	baseline      int
+
+	mu        sync.Mutex
+	used      map[string]time.Time
+	lastSweep time.Time
+	minute    time.Time
+	current   int
+	previous  int
+	now       func() time.Time
+}
+
+func newChallengeIssuer(config Config) *challengeIssuer {
+	secret := config.ChallengeSecret
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			panic(fmt.Sprintf("generating challenge secret: %v", err))
+		}
+	}
+
+	return &challengeIssuer{
+		secret:        secret,
+		ttl:           config.ChallengeTTL,
+		minDifficulty: config.MinChallengeDifficulty,
+		maxDifficulty: config.MaxChallengeDifficulty,
+		baseline:      config.ChallengeBaselineVolume,
+		used:          make(map[string]time.Time),
+		now:           time.Now,
+	}
+}
+
+// advance moves the report volume window to the current minute. The
+// caller must hold the lock.
+func (c *challengeIssuer) advance(now time.Time) {
+	minute := now.Truncate(time.Minute)
+	switch {
+	case minute.Equal(c.minute):
+	case minute.Equal(c.minute.Add(time.Minute)):
+		c.previous, c.current = c.current, 0
+	default:
+		c.previous, c.current = 0, 0
+	}
+	c.minute = minute
+}
+
+// record counts received reports towards the report volume
+func (c *challengeIssuer) record(reports int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.advance(c.now())
+	c.current += reports
+}
+
+// difficulty returns the number of leading zero bits required of new
+// challenges. Difficulty rises by one bit each time the report volume over
+// the last minute doubles beyond the baseline.
+func (c *challengeIssuer) difficulty() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.advance(c.now())
+	volume := max(c.current, c.previous)
+
+	difficulty := c.minDifficulty
+	for threshold := c.baseline; volume > threshold && difficulty < c.maxDifficulty; threshold *= 2 {
+		difficulty++
+	}
+	return difficulty
+}
+
+// sign returns the hex-encoded signature of a challenge payload
+func (c *challengeIssuer) sign(payload string) string {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// issue returns a new challenge at the current difficulty
+func (c *challengeIssuer) issue() (models.Challenge, error) {
+	nonce := make([]byte, challengeNonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return models.Challenge{}, err
+	}
+
+	difficulty := c.difficulty()
+	expiresAt := c.now().UTC().Add(c.ttl).Truncate(time.Second)
+	payload := fmt.Sprintf("%s.%d.%d", hex.EncodeToString(nonce), difficulty, expiresAt.Unix())
+
+	return models.Challenge{
+		Challenge:  payload + "." + c.sign(payload),
+		Difficulty: difficulty,
+		ExpiresAt:  expiresAt,
+	}, nil
+}
+
+// leadingZeroBits returns the number of leading zero bits of a hash
+func leadingZeroBits(sum []byte) int {
+	count := 0
+	for _, b := range sum {
+		if b != 0 {
+			return count + bits.LeadingZeros8(b)
+		}
+		count += 8
+	}
+	return count
+}
+
+// verify checks that a solution solves a challenge for the given number of
+// reports and marks the challenge as used. Each doubling of the number of
+// reports requires an extra bit of work so batches cost as much as the
+// reports they contain.
+func (c *challengeIssuer) verify(token, solution string, reports int) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 4 || !hmac.Equal([]byte(parts[3]), []byte(c.sign(strings.Join(parts[:3], ".")))) {
+		return errors.New("Invalid challenge")
+	}
+
+	difficulty, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return errors.New("Invalid challenge")
+	}
+	expires, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return errors.New("Invalid challenge")
+	}
+	expiresAt := time.Unix(expires, 0)
+
+	required := difficulty + bits.Len(uint(max(reports, 1)-1))
+	sum := sha256.Sum256([]byte(token + ":" + solution))
+	if leadingZeroBits(sum[:]) < required {
+		return errors.New("Insufficient proof of work")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if !now.Before(expiresAt) {
+		return errors.New("Challenge expired")
+	}
+
+	if now.Sub(c.lastSweep) >= c.ttl {
+		for nonce, expiry := range c.used {
+			if !now.Before(expiry) {
+				delete(c.used, nonce)
+			}
+		}
+		c.lastSweep = now
+	}
+
+	if _, ok := c.used[parts[0]]; ok {
+		return errors.New("Challenge already used")
+	}
+	c.used[parts[0]] = expiresAt
+
+	return nil
+}
+
+// checkProofOfWork verifies the challenge solved for a report request when
+// proof of work is required
+func (h *Handler) checkProofOfWork(r *http.Request, reports int) error {
+	if !h.config.RequireProofOfWork {
+		return nil
+	}
+
+	token := r.Header.Get(challengeHeader)
+	if token == "" {
+		return errors.New("Proof of work required")
+	}
+	return h.challenges.verify(token, r.Header.Get(challengeSolutionHeader), reports)
+}
+
+// GetChallengeHandler handles GET /api/challenge
+func (h *Handler) GetChallengeHandler(w http.ResponseWriter, r *http.Request) {
+	challenge, err := h.challenges.issue()
+	if err != nil {
+		WriteProblem(w, r, http.StatusInternalServerError, codeInternalError, "", "Error generating challenge")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(challenge)
+}
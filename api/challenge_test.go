@@ -0,0 +1,188 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/takedown-observer/backend/models"
+)
+
+// solveChallenge returns a solution with at least the given number of
+// leading zero bits, or with fewer if insufficient is set
+func solveChallenge(token string, difficulty int, insufficient bool) string {
+	for i := 0; ; i++ {
+		solution := strconv.Itoa(i)
+		sum := sha256.Sum256([]byte(token + ":" + solution))
+		if zeros := leadingZeroBits(sum[:]); (zeros >= difficulty) != insufficient {
+			return solution
+		}
+	}
+}
+
+func testChallengeConfig() Config {
+	config := DefaultConfig()
+	config.ChallengeSecret = []byte("secret")
+	config.MinChallengeDifficulty = 4
+	config.MaxChallengeDifficulty = 6
+	config.ChallengeBaselineVolume = 10
+	return config
+}
+
+func TestLeadingZeroBits(t *testing.T) {
+	tests := []struct {
+		sum      []byte
+		expected int
+	}{
+		{sum: []byte{0x80, 0x00}, expected: 0},
+		{sum: []byte{0x01, 0xff}, expected: 7},
+		{sum: []byte{0x00, 0x20}, expected: 10},
+		{sum: []byte{0x00, 0x00}, expected: 16},
+	}
+
+	for _, tt := range tests {
+		if got := leadingZeroBits(tt.sum); got != tt.expected {
+			t.Errorf("leadingZeroBits(%x) = %d, want %d", tt.sum, got, tt.expected)
+		}
+	}
+}
+
+func TestChallengeDifficulty(t *testing.T) {
+	now := time.Date(2025, 2, 20, 12, 0, 0, 0, time.UTC)
+	issuer := newChallengeIssuer(testChallengeConfig())
+	issuer.now = func() time.Time { return now }
+
+	steps := []struct {
+		record   int
+		advance  time.Duration
+		expected int
+	}{
+		{record: 10, expected: 4},
+		{record: 1, expected: 5},
+		{record: 10, expected: 6},
+		{record: 100, expected: 6},
+		{advance: time.Minute, expected: 6},
+		{advance: time.Minute, expected: 4},
+	}
+
+	for i, step := range steps {
+		now = now.Add(step.advance)
+		issuer.record(step.record)
+		if got := issuer.difficulty(); got != step.expected {
+			t.Errorf("Step %d: difficulty() = %d, want %d", i, got, step.expected)
+		}
+	}
+}
+
+func TestChallengeVerify(t *testing.T) {
+	now := time.Date(2025, 2, 20, 12, 0, 0, 0, time.UTC)
+	issuer := newChallengeIssuer(testChallengeConfig())
+	issuer.now = func() time.Time { return now }
+
+	issue := func(t *testing.T) string {
+		challenge, err := issuer.issue()
+		if err != nil {
+			t.Fatalf("issue() error = %v", err)
+		}
+		if challenge.Difficulty != 4 || !challenge.ExpiresAt.Equal(now.Add(5*time.Minute)) {
+			t.Errorf("Unexpected challenge %+v", challenge)
+		}
+		return challenge.Challenge
+	}
+
+	t.Run("single use", func(t *testing.T) {
+		token := issue(t)
+		solution := solveChallenge(token, 4, false)
+
+		if err := issuer.verify(token, solution, 1); err != nil {
+			t.Fatalf("verify() error = %v", err)
+		}
+		if err := issuer.verify(token, solution, 1); err == nil || err.Error() != "Challenge already used" {
+			t.Errorf("Expected reuse to fail, got %v", err)
+		}
+	})
+
+	t.Run("batch requires more work", func(t *testing.T) {
+		token := issue(t)
+
+		if err := issuer.verify(token, solveChallenge(token, 6, true), 4); err == nil || err.Error() != "Insufficient proof of work" {
+			t.Errorf("Expected insufficient work to fail, got %v", err)
+		}
+		if err := issuer.verify(token, solveChallenge(token, 6, false), 4); err != nil {
+			t.Errorf("verify() error = %v", err)
+		}
+	})
+
+	t.Run("tampered difficulty", func(t *testing.T) {
+		token := issue(t)
+		tampered := token[:33] + "0" + token[34:]
+
+		if err := issuer.verify(tampered, solveChallenge(tampered, 0, false), 1); err == nil || err.Error() != "Invalid challenge" {
+			t.Errorf("Expected tampered challenge to fail, got %v", err)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		token := issue(t)
+		solution := solveChallenge(token, 4, false)
+		now = now.Add(5 * time.Minute)
+
+		if err := issuer.verify(token, solution, 1); err == nil || err.Error() != "Challenge expired" {
+			t.Errorf("Expected expired challenge to fail, got %v", err)
+		}
+	})
+}
+
+func TestReportHandlerProofOfWork(t *testing.T) {
+	db := newTestDB(t)
+	config := testChallengeConfig()
+	config.RequireProofOfWork = true
+	handler := NewHandlerWithConfig(db, config)
+
+	body, _ := json.Marshal(models.ReportRequest{
+		ClientID: "123e4567-e89b-12d3-a456-426614174000",
+		Account: models.ReportedAccount{
+			ID:        "account1",
+			Name:      "Account1",
+			Countries: []string{"DE"},
+		},
+		DataFormatVersion: models.DataFormatVersion,
+	})
+
+	post := func(challenge models.Challenge, solution string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/report", bytes.NewBuffer(body))
+		if challenge.Challenge != "" {
+			req.Header.Set(challengeHeader, challenge.Challenge)
+			req.Header.Set(challengeSolutionHeader, solution)
+		}
+		w := httptest.NewRecorder()
+		handler.ReportHandler(w, req)
+		return w
+	}
+
+	if w := post(models.Challenge{}, ""); w.Code != http.StatusForbidden {
+		t.Errorf("Unsolved report status code = %v, want %v", w.Code, http.StatusForbidden)
+	}
+
+	req := httptest.NewRequest("GET", "/api/challenge", nil)
+	w := httptest.NewRecorder()
+	handler.GetChallengeHandler(w, req)
+
+	var challenge models.Challenge
+	if err := json.NewDecoder(w.Body).Decode(&challenge); err != nil {
+		t.Fatalf("Failed to decode challenge: %v", err)
+	}
+
+	solution := solveChallenge(challenge.Challenge, challenge.Difficulty, false)
+	if w := post(challenge, solution); w.Code != http.StatusOK {
+		t.Errorf("Solved report status code = %v, body = %s", w.Code, w.Body.String())
+	}
+	if w := post(challenge, solution); w.Code != http.StatusForbidden {
+		t.Errorf("Replayed report status code = %v, want %v", w.Code, http.StatusForbidden)
+	}
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/takedown-observer/backend/models"
+	"github.com/takedown-observer/backend/validation"
+	"gorm.io/gorm"
+)
+
+// Headers identifying the client that signed a report request and when
+const (
+	clientIDHeader           = "X-Client-ID"
+	signatureHeader          = "X-Signature"
+	signatureTimestampHeader = "X-Signature-Timestamp"
+)
+
+// RegisterClientHandler handles POST /api/clients/register
+func (h *Handler) RegisterClientHandler(w http.ResponseWriter, r *http.Request) {
+	body, ok := ReadBody(w, r, MaxReportSize)
+	if !ok {
+		return
+	}
+
+	var request models.ClientRegistrationRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidBody, errInvalidBody)
+		return
+	}
+
+	if _, err := parsePublicKey(request.PublicKey); err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err)
+		return
+	}
+
+	client := models.Client{
+		ID:           uuid.NewString(),
+		PublicKey:    request.PublicKey,
+		RegisteredAt: time.Now().UTC(),
+	}
+	if err := h.db.Create(&client).Error; err != nil {
+		writeDatabaseError(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(models.ClientRegistration{ClientID: client.ID})
+}
+
+// parsePublicKey decodes a base64url-encoded Ed25519 public key
+func parsePublicKey(value string) (ed25519.PublicKey, error) {
+	key, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, validation.Errorf("public_key", validation.CodeInvalidFormat, "Public key must be a base64url-encoded Ed25519 key")
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// signedMessage returns the message clients sign for a request: the request
+// timestamp, a dot and the request body. Signing the timestamp keeps
+// captured requests from being replayed later.
+func signedMessage(timestamp string, body []byte) []byte {
+	message := make([]byte, 0, len(timestamp)+1+len(body))
+	message = append(message, timestamp...)
+	message = append(message, '.')
+	return append(message, body...)
+}
+
+// verifySignature returns the registered client that signed the request
+// body, or an error if the request is unsigned, the signature is invalid or
+// its timestamp is outside the allowed window. Signatures are not verified
+// when signature checks are off.
+func (h *Handler) verifySignature(r *http.Request, body []byte) (string, error) {
+	if h.config.SignatureMode == SignatureModeOff {
+		return "", nil
+	}
+
+	clientID := r.Header.Get(clientIDHeader)
+	signature := r.Header.Get(signatureHeader)
+	timestamp := r.Header.Get(signatureTimestampHeader)
+	if clientID == "" || signature == "" || timestamp == "" {
+		return "", errors.New("Request is not signed")
+	}
+
+	signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return "", errors.New("Invalid signature timestamp")
+	}
+	if age := time.Since(time.Unix(signedAt, 0)); age > h.config.SignatureMaxAge || age < -h.config.SignatureMaxAge {
+		return "", errors.New("Signature timestamp is outside the allowed window")
+	}
+
+	var client models.Client
+	if err := h.db.First(&client, "id = ?", clientID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return "", errors.New("Unknown client")
+		}
+		log.Printf("Error loading client for signature check: %v", err)
+		return "", errors.New("Unable to verify signature")
+	}
+
+	publicKey, err := parsePublicKey(client.PublicKey)
+	if err != nil {
+		return "", errors.New("Unknown client")
+	}
+
+	decoded, err := hex.DecodeString(signature)
+	if err != nil || !ed25519.Verify(publicKey, signedMessage(timestamp, body), decoded) {
+		return "", errors.New("Invalid signature")
+	}
+
+	return client.ID, nil
+}
+
+// checkSigned reports whether a report from clientID may be accepted given
+// the result of verifying the request signature. Outside of enforcement
+// mode, reports are accepted and failures are only logged.
+func (h *Handler) checkSigned(signer string, verifyErr error, clientID string) error {
+	if h.config.SignatureMode == SignatureModeOff {
+		return nil
+	}
+
+	err := verifyErr
+	if err == nil && signer != clientID {
+		err = errors.New("Report is not signed by its client")
+	}
+	if err == nil {
+		return nil
+	}
+
+	if h.config.SignatureMode == SignatureModeEnforce {
+		return err
+	}
+	log.Printf("Accepting report with failed signature check from client %s: %v", h.hashClientID(clientID), err)
+	return nil
+}
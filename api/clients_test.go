@@ -0,0 +1,282 @@
+package api
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/takedown-observer/backend/models"
+	"gorm.io/gorm"
+)
+
+// testClient is a registered client along with its private key
+type testClient struct {
+	ClientID string
+	Key      ed25519.PrivateKey
+}
+
+// signRequest returns the hex-encoded signature of a request by a client
+func signRequest(key ed25519.PrivateKey, timestamp string, body []byte) string {
+	return hex.EncodeToString(ed25519.Sign(key, signedMessage(timestamp, body)))
+}
+
+func registerClient(t *testing.T, handler *Handler) testClient {
+	t.Helper()
+
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	body, _ := json.Marshal(models.ClientRegistrationRequest{PublicKey: base64.RawURLEncoding.EncodeToString(publicKey)})
+
+	req := httptest.NewRequest("POST", "/api/clients/register", bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+	handler.RegisterClientHandler(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("RegisterClientHandler() status code = %v, want %v", w.Code, http.StatusCreated)
+	}
+
+	var registration models.ClientRegistration
+	if err := json.NewDecoder(w.Body).Decode(&registration); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return testClient{ClientID: registration.ClientID, Key: privateKey}
+}
+
+func TestRegisterClientHandler(t *testing.T) {
+	db := newTestDB(t)
+	handler := NewHandler(db)
+
+	first := registerClient(t, handler)
+	second := registerClient(t, handler)
+
+	if first.ClientID == second.ClientID {
+		t.Errorf("Expected distinct registrations, got %v and %v", first.ClientID, second.ClientID)
+	}
+
+	var client models.Client
+	if err := db.First(&client, "id = ?", first.ClientID).Error; err != nil {
+		t.Fatalf("Registered client not stored: %v", err)
+	}
+	if client.PublicKey != base64.RawURLEncoding.EncodeToString(first.Key.Public().(ed25519.PublicKey)) {
+		t.Errorf("Stored public key does not match registered key")
+	}
+
+	for _, body := range []string{`{}`, `{"public_key":"c2hvcnQ"}`, `{"public_key":"not base64!"}`, `{`} {
+		req := httptest.NewRequest("POST", "/api/clients/register", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		handler.RegisterClientHandler(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("RegisterClientHandler(%s) status code = %v, want %v", body, w.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestReportHandlerSignatures(t *testing.T) {
+	report := func(clientID string) []byte {
+		body, _ := json.Marshal(models.ReportRequest{
+			ClientID: clientID,
+			Account: models.ReportedAccount{
+				ID:        "account1",
+				Name:      "Account1",
+				Countries: []string{"DE"},
+			},
+			DataFormatVersion: models.DataFormatVersion,
+		})
+		return body
+	}
+
+	tests := []struct {
+		name         string
+		mode         string
+		sign         func(client testClient, timestamp string, body []byte) (string, string)
+		signedAgo    time.Duration
+		unregistered bool
+		expectedCode int
+	}{
+		{
+			name:         "off accepts unsigned report",
+			mode:         SignatureModeOff,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "log accepts unsigned report",
+			mode:         SignatureModeLog,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "enforce rejects unsigned report",
+			mode:         SignatureModeEnforce,
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name: "enforce accepts signed report",
+			mode: SignatureModeEnforce,
+			sign: func(client testClient, timestamp string, body []byte) (string, string) {
+				return client.ClientID, signRequest(client.Key, timestamp, body)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name: "enforce rejects invalid signature",
+			mode: SignatureModeEnforce,
+			sign: func(client testClient, timestamp string, body []byte) (string, string) {
+				return client.ClientID, signRequest(ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize)), timestamp, body)
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name: "enforce rejects stale signature",
+			mode: SignatureModeEnforce,
+			sign: func(client testClient, timestamp string, body []byte) (string, string) {
+				return client.ClientID, signRequest(client.Key, timestamp, body)
+			},
+			signedAgo:    10 * time.Minute,
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name: "enforce rejects signature over another timestamp",
+			mode: SignatureModeEnforce,
+			sign: func(client testClient, timestamp string, body []byte) (string, string) {
+				return client.ClientID, signRequest(client.Key, "1700000000", body)
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name: "enforce rejects unknown client",
+			mode: SignatureModeEnforce,
+			sign: func(client testClient, timestamp string, body []byte) (string, string) {
+				return "223e4567-e89b-12d3-a456-426614174000", signRequest(client.Key, timestamp, body)
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name: "enforce rejects report for another client",
+			mode: SignatureModeEnforce,
+			sign: func(client testClient, timestamp string, body []byte) (string, string) {
+				return client.ClientID, signRequest(client.Key, timestamp, body)
+			},
+			unregistered: true,
+			expectedCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			config := DefaultConfig()
+			config.SignatureMode = tt.mode
+			handler := NewHandlerWithConfig(db, config)
+
+			client := registerClient(t, handler)
+			clientID := client.ClientID
+			if tt.unregistered {
+				clientID = "123e4567-e89b-12d3-a456-426614174000"
+			}
+			body := report(clientID)
+
+			req := httptest.NewRequest("POST", "/api/report", bytes.NewBuffer(body))
+			if tt.sign != nil {
+				timestamp := strconv.FormatInt(time.Now().Add(-tt.signedAgo).Unix(), 10)
+				signer, signature := tt.sign(client, timestamp, body)
+				req.Header.Set(clientIDHeader, signer)
+				req.Header.Set(signatureHeader, signature)
+				req.Header.Set(signatureTimestampHeader, timestamp)
+			}
+			w := httptest.NewRecorder()
+			handler.ReportHandler(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("ReportHandler() status code = %v, want %v, body = %s", w.Code, tt.expectedCode, w.Body.String())
+			}
+
+			var count int64
+			db.Model(&models.Account{}).Count(&count)
+			if stored := count == 1; stored != (tt.expectedCode == http.StatusOK) {
+				t.Errorf("Expected account stored = %v, got %d accounts", tt.expectedCode == http.StatusOK, count)
+			}
+		})
+	}
+}
+
+func TestReportHandlerChecksProofOfWorkFirst(t *testing.T) {
+	config := testChallengeConfig()
+	config.RequireProofOfWork = true
+	config.SignatureMode = SignatureModeEnforce
+	handler := NewHandlerWithConfig(newTestDB(t), config)
+
+	body, _ := json.Marshal(models.ReportRequest{
+		ClientID:          "123e4567-e89b-12d3-a456-426614174000",
+		Account:           models.ReportedAccount{ID: "account1", Name: "Account1", Countries: []string{"DE"}},
+		DataFormatVersion: models.DataFormatVersion,
+	})
+	req := httptest.NewRequest("POST", "/api/report", bytes.NewBuffer(body))
+	req.Header.Set(clientIDHeader, "123e4567-e89b-12d3-a456-426614174000")
+	req.Header.Set(signatureHeader, "invalid")
+	req.Header.Set(signatureTimestampHeader, strconv.FormatInt(time.Now().Unix(), 10))
+	w := httptest.NewRecorder()
+	handler.ReportHandler(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("ReportHandler() status code = %v, want %v", w.Code, http.StatusForbidden)
+	}
+}
+
+func TestBatchReportHandlerSignatures(t *testing.T) {
+	db := newTestDB(t)
+	config := DefaultConfig()
+	config.SignatureMode = SignatureModeEnforce
+	handler := NewHandlerWithConfig(db, config)
+
+	client := registerClient(t, handler)
+	reports := []models.ReportRequest{
+		{
+			ClientID:          client.ClientID,
+			Account:           models.ReportedAccount{ID: "account1", Name: "Account1", Countries: []string{"DE"}},
+			DataFormatVersion: models.DataFormatVersion,
+		},
+		{
+			ClientID:          "123e4567-e89b-12d3-a456-426614174000",
+			Account:           models.ReportedAccount{ID: "account2", Name: "Account2", Countries: []string{"DE"}},
+			DataFormatVersion: models.DataFormatVersion,
+		},
+	}
+	body, _ := json.Marshal(reports)
+
+	req := httptest.NewRequest("POST", "/api/reports/batch", bytes.NewBuffer(body))
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set(clientIDHeader, client.ClientID)
+	req.Header.Set(signatureHeader, signRequest(client.Key, timestamp, body))
+	req.Header.Set(signatureTimestampHeader, timestamp)
+	w := httptest.NewRecorder()
+	handler.BatchReportHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("BatchReportHandler() status code = %v, want %v", w.Code, http.StatusOK)
+	}
+
+	var response models.BatchReportResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if response.Results[0].Status != models.BatchStatusSuccess {
+		t.Errorf("Expected signed report to succeed, got %+v", response.Results[0])
+	}
+	if response.Results[1].Status != models.BatchStatusError || response.Results[1].Error != "Report is not signed by its client" {
+		t.Errorf("Expected report from another client to fail, got %+v", response.Results[1])
+	}
+
+	if err := db.First(&models.Account{}, "id = ?", "account2").Error; err != gorm.ErrRecordNotFound {
+		t.Errorf("Expected account2 not to be stored, got %v", err)
+	}
+}
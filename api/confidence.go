@@ -0,0 +1,111 @@
+package api
+
+import (
+	"time"
+
+	"github.com/takedown-observer/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// recordConfirmations records that a client observed an account withheld in
+// the given countries. Repeated reports from the same client only refresh
+// the confirmation time.
+func recordConfirmations(tx *gorm.DB, platform, accountID, clientHash string, countries []string, at time.Time) error {
+	if len(countries) == 0 {
+		return nil
+	}
+
+	rows := make([]models.CountryConfirmation, len(countries))
+	for i, country := range countries {
+		rows[i] = models.CountryConfirmation{
+			Platform:        platform,
+			AccountID:       accountID,
+			CountryCode:     country,
+			ClientHash:      clientHash,
+			LastConfirmedAt: at,
+		}
+	}
+
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "platform"}, {Name: "account_id"}, {Name: "country_code"}, {Name: "client_hash"}},
+		DoUpdates: clause.AssignmentColumns([]string{"last_confirmed_at"}),
+	}).Create(&rows).Error
+}
+
+// confidence returns the share of an account's reporters that observed a
+// country. Accounts reported before confirmations were recorded may have
+// fewer confirmations than reporters, but never more.
+func confidence(reporters, reportCount int) float64 {
+	switch {
+	case reporters <= 0:
+		return 0
+	case reporters >= reportCount:
+		return 1
+	default:
+		return float64(reporters) / float64(reportCount)
+	}
+}
+
+// countryReporters returns the number of clients that confirmed each country
+// of the given accounts, keyed by account and country code
+func countryReporters(db *gorm.DB, accounts []models.Account) (map[accountKey]map[string]int, error) {
+	keys := accountKeyValues(accounts)
+
+	var rows []struct {
+		Platform    string
+		AccountID   string
+		CountryCode string
+		Reporters   int
+	}
+
+	// Confirmations whose reporter links were purged are still counted
+	result := db.Raw(`SELECT platform, account_id, country_code, SUM(count) AS reporters FROM (
+			SELECT platform, account_id, country_code, COUNT(*) AS count FROM country_confirmations
+			WHERE (platform, account_id) IN ? GROUP BY platform, account_id, country_code
+			UNION ALL
+			SELECT platform, account_id, country_code, count FROM purged_confirmations
+			WHERE (platform, account_id) IN ?
+		) GROUP BY platform, account_id, country_code`, keys, keys).
+		Scan(&rows)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	reporters := make(map[accountKey]map[string]int)
+	for _, row := range rows {
+		key := accountKey{Platform: row.Platform, ID: row.AccountID}
+		if reporters[key] == nil {
+			reporters[key] = make(map[string]int)
+		}
+		reporters[key][row.CountryCode] = row.Reporters
+	}
+
+	return reporters, nil
+}
+
+// loadCountryConfidence fills in the confidence of each country the accounts
+// are currently withheld in
+func loadCountryConfidence(db *gorm.DB, accounts []models.Account) error {
+	if len(accounts) == 0 {
+		return nil
+	}
+
+	reporters, err := countryReporters(db, accounts)
+	if err != nil {
+		return err
+	}
+
+	for i := range accounts {
+		for _, country := range accounts[i].Countries {
+			count := reporters[keyOf(accounts[i])][country]
+			accounts[i].CountryConfidence = append(accounts[i].CountryConfidence, models.CountryConfidence{
+				Country:    country,
+				Reporters:  count,
+				Confidence: confidence(count, accounts[i].ReportCount),
+			})
+		}
+	}
+
+	return nil
+}
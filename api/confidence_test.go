@@ -0,0 +1,142 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/takedown-observer/backend/db"
+	"github.com/takedown-observer/backend/models"
+)
+
+func TestConfidence(t *testing.T) {
+	tests := []struct {
+		name        string
+		reporters   int
+		reportCount int
+		expected    float64
+	}{
+		{name: "unconfirmed", reporters: 0, reportCount: 3, expected: 0},
+		{name: "partially confirmed", reporters: 1, reportCount: 4, expected: 0.25},
+		{name: "fully confirmed", reporters: 3, reportCount: 3, expected: 1},
+		{name: "more confirmations than reporters", reporters: 2, reportCount: 1, expected: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := confidence(tt.reporters, tt.reportCount); got != tt.expected {
+				t.Errorf("confidence(%d, %d) = %v, want %v", tt.reporters, tt.reportCount, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetAccountsHandlerConfidence(t *testing.T) {
+	testDB := newTestDB(t)
+	handler := NewHandler(testDB)
+
+	clients := []string{
+		"123e4567-e89b-12d3-a456-426614174000",
+		"223e4567-e89b-12d3-a456-426614174000",
+		"323e4567-e89b-12d3-a456-426614174000",
+	}
+
+	// DE is confirmed by every client and FR by two of them, although the
+	// second client's report without FR is not the latest
+	postReport(t, handler, clients[0], "account1", "Account1", []string{"DE", "FR"})
+	postReport(t, handler, clients[1], "account1", "Account1", []string{"DE"})
+	postReport(t, handler, clients[2], "account1", "Account1", []string{"DE", "FR"})
+	postReport(t, handler, clients[2], "account1", "Account1", []string{"DE", "FR"})
+	postReport(t, handler, clients[0], "account2", "Account2", []string{"FR"})
+
+	list := func(t *testing.T, query string) models.AccountsResponse {
+		t.Helper()
+
+		req := httptest.NewRequest("GET", "/api/accounts?"+query, nil)
+		w := httptest.NewRecorder()
+		handler.GetAccountsHandler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("GetAccountsHandler() status code = %v, body = %s", w.Code, w.Body.String())
+		}
+
+		var response models.AccountsResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return response
+	}
+
+	ids := func(response models.AccountsResponse) []string {
+		ids := []string{}
+		for _, account := range response.Accounts {
+			ids = append(ids, account.ID)
+		}
+		return ids
+	}
+
+	t.Run("reports confidence per country", func(t *testing.T) {
+		response := list(t, "country=DE")
+		if len(response.Accounts) != 1 {
+			t.Fatalf("Expected 1 account, got %v", ids(response))
+		}
+
+		expected := []models.CountryConfidence{
+			{Country: "DE", Reporters: 3, Confidence: 1},
+			{Country: "FR", Reporters: 2, Confidence: 2.0 / 3},
+		}
+		if got := response.Accounts[0].CountryConfidence; !reflect.DeepEqual(got, expected) {
+			t.Errorf("Expected confidence %v, got %v", expected, got)
+		}
+	})
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{name: "unconfirmed filter", query: "country=FR&sort=name&order=asc", expected: []string{"account1", "account2"}},
+		{name: "confirmed country", query: "country=DE&min_reporters=3", expected: []string{"account1"}},
+		{name: "insufficiently confirmed country", query: "country=FR&min_reporters=3", expected: []string{}},
+		{name: "any confirmed country", query: "min_reporters=2", expected: []string{"account1"}},
+		{name: "confirmed exclusion", query: "exclude_country=FR&min_reporters=2", expected: []string{}},
+		{name: "unconfirmed exclusion", query: "exclude_country=FR&min_reporters=3", expected: []string{"account1"}},
+		{name: "confirmed country count", query: "min_countries=2&min_reporters=3", expected: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ids(list(t, tt.query)); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected accounts %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	t.Run("counts purged confirmations", func(t *testing.T) {
+		if err := db.PurgeReporters(testDB, time.Now().UTC().Add(time.Hour)); err != nil {
+			t.Fatalf("PurgeReporters() error = %v", err)
+		}
+
+		if got := ids(list(t, "country=DE&min_reporters=3")); !reflect.DeepEqual(got, []string{"account1"}) {
+			t.Errorf("Expected accounts [account1], got %v", got)
+		}
+
+		response := list(t, "country=FR&sort=name&order=asc")
+		if got := response.Accounts[0].CountryConfidence[1]; got.Reporters != 2 {
+			t.Errorf("Expected FR to keep 2 reporters, got %+v", got)
+		}
+	})
+
+	t.Run("invalid min_reporters", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/accounts?min_reporters=-1", nil)
+		w := httptest.NewRecorder()
+		handler.GetAccountsHandler(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("GetAccountsHandler() status code = %v, want %v", w.Code, http.StatusBadRequest)
+		}
+	})
+}
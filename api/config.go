@@ -0,0 +1,71 @@
+package api
+
+import "time"
+
+// Report signature enforcement modes
+const (
+	// SignatureModeOff accepts reports without verifying signatures
+	SignatureModeOff = "off"
+	// SignatureModeLog verifies signatures and logs reports that fail
+	// verification, but accepts them
+	SignatureModeLog = "log"
+	// SignatureModeEnforce rejects reports that are not signed by their
+	// registered client
+	SignatureModeEnforce = "enforce"
+)
+
+// MaxReportSize is the maximum size in bytes of a single report, which
+// leaves room for the longest valid fields even when JSON-escaped
+const MaxReportSize = 16 << 10
+
+// Config holds the tunable settings of a Handler
+type Config struct {
+	// MaxBatchSize is the maximum number of reports accepted by a single
+	// batch request
+	MaxBatchSize int
+
+	// ClientHashKey keys the hashes client IDs are stored under. It must
+	// match the key the database was migrated with and must not change.
+	ClientHashKey []byte
+
+	// SignatureMode determines how reports without a valid client
+	// signature are treated
+	SignatureMode string
+
+	// SignatureMaxAge is how far the timestamp of a signed request may be
+	// from the current time, in either direction
+	SignatureMaxAge time.Duration
+
+	// RequireProofOfWork requires reports to carry a solved challenge
+	// from GET /api/challenge
+	RequireProofOfWork bool
+
+	// ChallengeSecret signs issued challenges. If empty, a random secret is
+	// generated, which invalidates outstanding challenges on restart.
+	ChallengeSecret []byte
+
+	// ChallengeTTL is how long an issued challenge can be used
+	ChallengeTTL time.Duration
+
+	// MinChallengeDifficulty and MaxChallengeDifficulty bound the number
+	// of leading zero bits required of challenge solutions
+	MinChallengeDifficulty int
+	MaxChallengeDifficulty int
+
+	// ChallengeBaselineVolume is the number of reports per minute above
+	// which challenge difficulty rises by a bit with each doubling
+	ChallengeBaselineVolume int
+}
+
+// DefaultConfig returns the configuration used by NewHandler
+func DefaultConfig() Config {
+	return Config{
+		MaxBatchSize:            100,
+		SignatureMode:           SignatureModeOff,
+		SignatureMaxAge:         5 * time.Minute,
+		ChallengeTTL:            5 * time.Minute,
+		MinChallengeDifficulty:  18,
+		MaxChallengeDifficulty:  26,
+		ChallengeBaselineVolume: 60,
+	}
+}
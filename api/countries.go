@@ -0,0 +1,103 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/takedown-observer/backend/models"
+	"github.com/takedown-observer/backend/validation"
+	"gorm.io/gorm"
+)
+
+// countryCache caches the per-country account counts aggregated from the
+// country join table, by platform. Counts across all platforms are cached
+// under the empty platform. The cache is invalidated whenever a stored report
+// changes the countries an account is withheld in.
+type countryCache struct {
+	mu         sync.Mutex
+	counts     map[string][]models.CountryCount
+	generation uint64
+}
+
+// get returns the cached counts for a platform, or all platforms if it is
+// empty, loading them from the database if the cache has been invalidated
+func (c *countryCache) get(db *gorm.DB, platform string) ([]models.CountryCount, error) {
+	c.mu.Lock()
+	if counts, ok := c.counts[platform]; ok {
+		c.mu.Unlock()
+		return counts, nil
+	}
+	generation := c.generation
+	c.mu.Unlock()
+
+	query := db.Model(&models.AccountCountry{})
+	if platform != "" {
+		query = query.Where("account_countries.platform = ?", platform)
+	}
+
+	counts := []models.CountryCount{}
+	err := query.
+		Select("country_code AS country, COUNT(*) AS accounts").
+		Group("country_code").
+		Order("country_code").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Only cache the result if no write happened while it was loading
+	c.mu.Lock()
+	if c.generation == generation {
+		if c.counts == nil {
+			c.counts = make(map[string][]models.CountryCount)
+		}
+		c.counts[platform] = counts
+	}
+	c.mu.Unlock()
+
+	return counts, nil
+}
+
+// invalidate discards the cached counts of all platforms
+func (c *countryCache) invalidate() {
+	c.mu.Lock()
+	c.counts = nil
+	c.generation++
+	c.mu.Unlock()
+}
+
+// uniqueCountries returns the sorted codes of all countries any account on
+// the platform is withheld in, or any account at all if it is empty
+func (h *Handler) uniqueCountries(platform string) ([]string, error) {
+	counts, err := h.countries.get(h.db, platform)
+	if err != nil {
+		return nil, err
+	}
+
+	countries := make([]string, len(counts))
+	for i, count := range counts {
+		countries[i] = count.Country
+	}
+	return countries, nil
+}
+
+// GetCountriesHandler handles GET /api/countries
+func (h *Handler) GetCountriesHandler(w http.ResponseWriter, r *http.Request) {
+	platform := r.URL.Query().Get("platform")
+	if platform != "" {
+		if err := validation.ValidatePlatform(platform); err != nil {
+			writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err)
+			return
+		}
+	}
+
+	counts, err := h.countries.get(h.db, platform)
+	if err != nil {
+		writeDatabaseError(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.CountriesResponse{Countries: counts})
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/takedown-observer/backend/models"
+)
+
+func TestGetCountriesHandler(t *testing.T) {
+	db := newTestDB(t)
+	handler := NewHandler(db)
+	clientID := "123e4567-e89b-12d3-a456-426614174000"
+
+	fetch := func(params ...string) []models.CountryCount {
+		target := "/api/countries"
+		if len(params) > 0 {
+			target += "?" + params[0]
+		}
+		req := httptest.NewRequest("GET", target, nil)
+		w := httptest.NewRecorder()
+		handler.GetCountriesHandler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("GetCountriesHandler() status code = %v, want %v", w.Code, http.StatusOK)
+		}
+
+		var response models.CountriesResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return response.Countries
+	}
+
+	if counts := fetch(); len(counts) != 0 {
+		t.Errorf("Expected no countries, got %v", counts)
+	}
+
+	postReport(t, handler, clientID, "account1", "Account1", []string{"DE", "FR"})
+	postReport(t, handler, clientID, "account2", "Account2", []string{"DE"})
+
+	expected := []models.CountryCount{{Country: "DE", Accounts: 2}, {Country: "FR", Accounts: 1}}
+	if counts := fetch(); !reflect.DeepEqual(counts, expected) {
+		t.Errorf("Expected counts %v, got %v", expected, counts)
+	}
+
+	// Writes bypassing the handler are not seen until the cache is invalidated
+	db.Create(&models.Account{ID: "account3", Name: "Account3", Countries: []string{"IN"}})
+	if counts := fetch(); !reflect.DeepEqual(counts, expected) {
+		t.Errorf("Expected cached counts %v, got %v", expected, counts)
+	}
+
+	// Reports that do not change any countries keep the cache
+	postReport(t, handler, clientID, "account2", "Account2", []string{"DE"})
+	if counts := fetch(); !reflect.DeepEqual(counts, expected) {
+		t.Errorf("Expected cached counts %v after unchanged report, got %v", expected, counts)
+	}
+
+	postReport(t, handler, clientID, "account1", "Account1", []string{"FR"})
+
+	expected = []models.CountryCount{{Country: "DE", Accounts: 1}, {Country: "FR", Accounts: 1}, {Country: "IN", Accounts: 1}}
+	if counts := fetch(); !reflect.DeepEqual(counts, expected) {
+		t.Errorf("Expected counts %v after report, got %v", expected, counts)
+	}
+
+	// Counts are cached separately for each platform
+	db.Create(&models.Account{Platform: "telegram", ID: "channel1", Name: "Channel1", Countries: []string{"DE", "RU"}})
+	handler.countries.invalidate()
+
+	expected = []models.CountryCount{{Country: "DE", Accounts: 1}, {Country: "RU", Accounts: 1}}
+	if counts := fetch("platform=telegram"); !reflect.DeepEqual(counts, expected) {
+		t.Errorf("Expected telegram counts %v, got %v", expected, counts)
+	}
+
+	countries, err := handler.uniqueCountries("x")
+	if err != nil {
+		t.Fatalf("uniqueCountries() unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(countries, []string{"DE", "FR", "IN"}) {
+		t.Errorf("Expected unique X countries [DE FR IN], got %v", countries)
+	}
+
+	countries, err = handler.uniqueCountries("")
+	if err != nil {
+		t.Fatalf("uniqueCountries() unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(countries, []string{"DE", "FR", "IN", "RU"}) {
+		t.Errorf("Expected unique countries [DE FR IN RU], got %v", countries)
+	}
+
+	req := httptest.NewRequest("GET", "/api/countries?platform=myspace", nil)
+	w := httptest.NewRecorder()
+	handler.GetCountriesHandler(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("GetCountriesHandler() status code = %v, want %v", w.Code, http.StatusBadRequest)
+	}
+}
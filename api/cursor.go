@@ -0,0 +1,91 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/takedown-observer/backend/models"
+	"github.com/takedown-observer/backend/validation"
+)
+
+// listingCursor identifies the position of an account or post in a sorted
+// listing. It is handed to clients as an opaque string.
+type listingCursor struct {
+	Sort     string `json:"s"`
+	Value    string `json:"v"`
+	Platform string `json:"p,omitempty"`
+	ID       string `json:"id"`
+}
+
+// encodeCursor returns the cursor pointing just past the given account
+func encodeCursor(sort listingSort, account models.Account) string {
+	return newCursor(sort, sort.value(account.LastReportedAt, account.FirstSeenAt, account.ReportCount, account.Name), account.Platform, account.ID)
+}
+
+// encodePostCursor returns the cursor pointing just past the given post
+func encodePostCursor(sort listingSort, post models.WithheldPost) string {
+	return newCursor(sort, sort.value(post.LastReportedAt, post.FirstSeenAt, post.ReportCount, ""), post.Platform, post.ID)
+}
+
+// newCursor encodes the cursor of a row with the given sort value
+func newCursor(sort listingSort, value, platform, id string) string {
+	data, _ := json.Marshal(listingCursor{
+		Sort:     sort.Column + " " + sort.Order,
+		Value:    value,
+		Platform: platform,
+		ID:       id,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses a cursor previously returned by encodeCursor
+func decodeCursor(value string) (listingCursor, error) {
+	var cursor listingCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, validation.Errorf("cursor", validation.CodeInvalidFormat, "Invalid cursor")
+	}
+
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" {
+		return cursor, validation.Errorf("cursor", validation.CodeInvalidFormat, "Invalid cursor")
+	}
+
+	// Cursors issued before accounts had platforms point at X accounts
+	if cursor.Platform == "" {
+		cursor.Platform = validation.DefaultPlatform
+	}
+
+	return cursor, nil
+}
+
+// eventCursor identifies the position of an event in the event listing,
+// which is ordered by time and ID
+type eventCursor struct {
+	OccurredAt time.Time `json:"t"`
+	ID         uint      `json:"id"`
+}
+
+// encodeEventCursor returns the cursor pointing just past the given event
+func encodeEventCursor(event models.CountryEvent) string {
+	data, _ := json.Marshal(eventCursor{OccurredAt: event.OccurredAt.UTC(), ID: event.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeEventCursor parses a cursor previously returned by
+// encodeEventCursor
+func decodeEventCursor(value string) (eventCursor, error) {
+	var cursor eventCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, validation.Errorf("cursor", validation.CodeInvalidFormat, "Invalid cursor")
+	}
+
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == 0 {
+		return cursor, validation.Errorf("cursor", validation.CodeInvalidFormat, "Invalid cursor")
+	}
+
+	return cursor, nil
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+
+	"github.com/takedown-observer/backend/models"
+	"github.com/takedown-observer/backend/validation"
+	"gorm.io/gorm"
+)
+
+// Event listing limits
+const (
+	defaultEventsLimit = 100
+	maxEventsLimit     = 1000
+)
+
+// diffCountries returns the countries present in current but not in previous
+// (added) and those present in previous but not in current (removed)
+func diffCountries(previous, current []string) (added, removed []string) {
+	for _, country := range current {
+		if !slices.Contains(previous, country) {
+			added = append(added, country)
+		}
+	}
+	for _, country := range previous {
+		if !slices.Contains(current, country) {
+			removed = append(removed, country)
+		}
+	}
+	return added, removed
+}
+
+// recordCountryEvents stores an imposed event for every country a reported
+// account is newly withheld in, and a lifted event for every country of the
+// previous countries it is no longer withheld in. Impositions take the reason
+// of the report, and lifts the reason of the imposition they end. It returns
+// whether the account's countries changed.
+func recordCountryEvents(tx *gorm.DB, account models.Account, previous []string) (bool, error) {
+	added, removed := diffCountries(previous, account.Countries)
+	platform, accountID, at := account.Platform, account.ID, account.LastReportedAt
+
+	events := make([]models.CountryEvent, 0, len(added)+len(removed))
+	for _, country := range added {
+		events = append(events, models.CountryEvent{
+			Platform:    platform,
+			AccountID:   accountID,
+			CountryCode: country,
+			Type:        models.EventImposed,
+			Reason:      account.Reason,
+			OccurredAt:  at,
+		})
+	}
+
+	for _, country := range removed {
+		event := models.CountryEvent{
+			Platform:    platform,
+			AccountID:   accountID,
+			CountryCode: country,
+			Type:        models.EventLifted,
+			Reason:      validation.ReasonUnspecified,
+			OccurredAt:  at,
+		}
+
+		// Link the lift to the matching imposition, if it was observed
+		var imposed models.CountryEvent
+		result := tx.Where("platform = ? AND account_id = ? AND country_code = ? AND type = ?", platform, accountID, country, models.EventImposed).
+			Order("occurred_at desc").
+			Limit(1).
+			Find(&imposed)
+		if result.Error != nil {
+			return false, result.Error
+		}
+		if result.RowsAffected > 0 {
+			event.ImposedAt = &imposed.OccurredAt
+			event.Reason = imposed.Reason
+		}
+
+		events = append(events, event)
+	}
+
+	if len(events) == 0 {
+		return false, nil
+	}
+	return true, tx.Create(&events).Error
+}
+
+// GetEventsHandler handles GET /api/events
+func (h *Handler) GetEventsHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	query := h.db.Model(&models.CountryEvent{})
+
+	switch eventType := params.Get("type"); eventType {
+	case "":
+	case models.EventImposed, models.EventLifted:
+		query = query.Where("type = ?", eventType)
+	default:
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, validation.Errorf("type", validation.CodeNotAllowed, "Invalid event type"))
+		return
+	}
+
+	if platform := params.Get("platform"); platform != "" {
+		if err := validation.ValidatePlatform(platform); err != nil {
+			writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err)
+			return
+		}
+		query = query.Where("platform = ?", platform)
+	}
+
+	if country := params.Get("country"); country != "" {
+		if err := validation.ValidateCountryCode(country); err != nil {
+			writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err)
+			return
+		}
+		query = query.Where("country_code = ?", country)
+	}
+
+	if since := params.Get("since"); since != "" {
+		sinceTime, err := validation.ParseTimestamp("since", since)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err)
+			return
+		}
+		query = query.Where("occurred_at >= ?", sinceTime.UTC())
+	}
+
+	limit, err := validation.ParseLimit(params.Get("limit"), defaultEventsLimit, maxEventsLimit)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err)
+		return
+	}
+
+	// Events are paged by time and ID, so pages stay stable as new events
+	// are recorded
+	if cursorParam := params.Get("cursor"); cursorParam != "" {
+		cursor, err := decodeEventCursor(cursorParam)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err)
+			return
+		}
+		query = query.Where("occurred_at < ? OR (occurred_at = ? AND id < ?)", cursor.OccurredAt, cursor.OccurredAt, cursor.ID)
+	}
+
+	// Fetch one extra event to determine whether a next page exists
+	events := []models.CountryEvent{}
+	if err := query.Order("occurred_at desc, id desc").Limit(limit + 1).Find(&events).Error; err != nil {
+		writeDatabaseError(w, r)
+		return
+	}
+
+	var nextCursor string
+	if len(events) > limit {
+		events = events[:limit]
+		nextCursor = encodeEventCursor(events[limit-1])
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.EventsResponse{Events: events, NextCursor: nextCursor})
+}
@@ -0,0 +1,283 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/takedown-observer/backend/models"
+	"gorm.io/gorm"
+)
+
+// postReport submits a report through ReportHandler and fails the test if it
+// is not accepted
+func postReport(t *testing.T, handler *Handler, clientID, accountID, name string, countries []string) {
+	t.Helper()
+
+	body, _ := json.Marshal(models.ReportRequest{
+		ClientID: clientID,
+		Account: models.ReportedAccount{
+			ID:        accountID,
+			Name:      name,
+			Countries: countries,
+		},
+		DataFormatVersion: models.DataFormatVersion,
+	})
+	req := httptest.NewRequest("POST", "/api/report", bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+
+	handler.ReportHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("ReportHandler() status code = %v, body = %s", w.Code, w.Body.String())
+	}
+}
+
+func TestDiffCountries(t *testing.T) {
+	tests := []struct {
+		name            string
+		previous        []string
+		current         []string
+		expectedAdded   []string
+		expectedRemoved []string
+	}{
+		{
+			name:          "new account",
+			previous:      nil,
+			current:       []string{"DE", "FR"},
+			expectedAdded: []string{"DE", "FR"},
+		},
+		{
+			name:     "unchanged",
+			previous: []string{"DE", "FR"},
+			current:  []string{"FR", "DE"},
+		},
+		{
+			name:            "added and removed",
+			previous:        []string{"DE", "FR"},
+			current:         []string{"FR", "IN"},
+			expectedAdded:   []string{"IN"},
+			expectedRemoved: []string{"DE"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added, removed := diffCountries(tt.previous, tt.current)
+			if !reflect.DeepEqual(added, tt.expectedAdded) {
+				t.Errorf("diffCountries() added = %v, want %v", added, tt.expectedAdded)
+			}
+			if !reflect.DeepEqual(removed, tt.expectedRemoved) {
+				t.Errorf("diffCountries() removed = %v, want %v", removed, tt.expectedRemoved)
+			}
+		})
+	}
+}
+
+func TestReportHandlerRecordsCountryEvents(t *testing.T) {
+	db := newTestDB(t)
+	handler := NewHandler(db)
+	clientID := "123e4567-e89b-12d3-a456-426614174000"
+
+	postReport(t, handler, clientID, "account1", "Account1", []string{"DE", "FR"})
+	postReport(t, handler, clientID, "account1", "Account1", []string{"FR", "IN"})
+
+	var events []models.CountryEvent
+	db.Order("id asc").Find(&events)
+
+	if len(events) != 4 {
+		t.Fatalf("Expected 4 events, got %d", len(events))
+	}
+
+	var lifted []models.CountryEvent
+	for _, event := range events {
+		if event.Type == models.EventLifted {
+			lifted = append(lifted, event)
+		}
+	}
+	if len(lifted) != 1 || lifted[0].CountryCode != "DE" {
+		t.Fatalf("Expected a single lifted event for DE, got %v", lifted)
+	}
+	if lifted[0].ImposedAt == nil || !lifted[0].ImposedAt.Equal(events[0].OccurredAt) {
+		t.Errorf("Expected lifted event to reference imposition at %v, got %v", events[0].OccurredAt, lifted[0].ImposedAt)
+	}
+}
+
+func TestGetEventsHandler(t *testing.T) {
+	base := time.Date(2025, 2, 20, 12, 0, 0, 0, time.UTC)
+
+	setupEvents := func(t *testing.T, db *gorm.DB) error {
+		events := []models.CountryEvent{
+			{AccountID: "account1", CountryCode: "DE", Type: models.EventImposed, OccurredAt: base},
+			{AccountID: "account1", CountryCode: "DE", Type: models.EventLifted, OccurredAt: base.Add(48 * time.Hour)},
+			{AccountID: "account2", CountryCode: "FR", Type: models.EventImposed, OccurredAt: base.Add(24 * time.Hour)},
+			{Platform: "telegram", AccountID: "channel1", CountryCode: "DE", Type: models.EventImposed, OccurredAt: base.Add(-24 * time.Hour)},
+		}
+		return db.Create(&events).Error
+	}
+
+	tests := []struct {
+		name          string
+		queryParams   map[string]string
+		expectedCode  int
+		expectedCount int
+	}{
+		{
+			name:          "all events",
+			queryParams:   map[string]string{},
+			expectedCode:  http.StatusOK,
+			expectedCount: 4,
+		},
+		{
+			name:          "platform",
+			queryParams:   map[string]string{"platform": "telegram"},
+			expectedCode:  http.StatusOK,
+			expectedCount: 1,
+		},
+		{
+			name:          "country on platform",
+			queryParams:   map[string]string{"platform": "x", "country": "DE"},
+			expectedCode:  http.StatusOK,
+			expectedCount: 2,
+		},
+		{
+			name:          "lifted only",
+			queryParams:   map[string]string{"type": "lifted"},
+			expectedCode:  http.StatusOK,
+			expectedCount: 1,
+		},
+		{
+			name:          "imposed in country",
+			queryParams:   map[string]string{"type": "imposed", "country": "FR"},
+			expectedCode:  http.StatusOK,
+			expectedCount: 1,
+		},
+		{
+			name:          "since",
+			queryParams:   map[string]string{"since": "2025-02-21T00:00:00Z"},
+			expectedCode:  http.StatusOK,
+			expectedCount: 2,
+		},
+		{
+			name:          "since with offset",
+			queryParams:   map[string]string{"since": "2025-02-21T14:00:00+05:00"},
+			expectedCode:  http.StatusOK,
+			expectedCount: 2,
+		},
+		{
+			name:         "invalid type",
+			queryParams:  map[string]string{"type": "removed"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid platform",
+			queryParams:  map[string]string{"platform": "myspace"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid country",
+			queryParams:  map[string]string{"country": "germany"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid since",
+			queryParams:  map[string]string{"since": "last week"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid cursor",
+			queryParams:  map[string]string{"cursor": "invalid"},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+
+			if err := setupEvents(t, db); err != nil {
+				t.Fatalf("Failed to setup test database: %v", err)
+			}
+
+			handler := NewHandler(db)
+
+			req := httptest.NewRequest("GET", "/api/events", nil)
+			q := req.URL.Query()
+			for key, value := range tt.queryParams {
+				q.Add(key, value)
+			}
+			req.URL.RawQuery = q.Encode()
+
+			w := httptest.NewRecorder()
+			handler.GetEventsHandler(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("GetEventsHandler() status code = %v, want %v", w.Code, tt.expectedCode)
+			}
+
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+
+			var response models.EventsResponse
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if len(response.Events) != tt.expectedCount {
+				t.Errorf("Expected %d events, got %d", tt.expectedCount, len(response.Events))
+			}
+		})
+	}
+}
+
+func TestGetEventsHandlerPaging(t *testing.T) {
+	db := newTestDB(t)
+	base := time.Date(2025, 2, 20, 12, 0, 0, 0, time.UTC)
+
+	// Events sharing a time are ordered by ID
+	events := []models.CountryEvent{
+		{AccountID: "account1", CountryCode: "DE", Type: models.EventImposed, OccurredAt: base},
+		{AccountID: "account2", CountryCode: "DE", Type: models.EventImposed, OccurredAt: base},
+		{AccountID: "account3", CountryCode: "FR", Type: models.EventImposed, OccurredAt: base.Add(time.Hour)},
+		{AccountID: "account1", CountryCode: "DE", Type: models.EventLifted, OccurredAt: base.Add(2 * time.Hour)},
+		{AccountID: "account4", CountryCode: "IN", Type: models.EventImposed, OccurredAt: base.Add(-time.Hour)},
+	}
+	if err := db.Create(&events).Error; err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	handler := NewHandler(db)
+
+	var ids []uint
+	target := "/api/events?limit=2"
+	for page := 0; page < 5; page++ {
+		req := httptest.NewRequest("GET", target, nil)
+		w := httptest.NewRecorder()
+		handler.GetEventsHandler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("GetEventsHandler() status code = %v (%s)", w.Code, w.Body.String())
+		}
+
+		var response models.EventsResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		for _, event := range response.Events {
+			ids = append(ids, event.ID)
+		}
+		if response.NextCursor == "" {
+			break
+		}
+		target = "/api/events?limit=2&cursor=" + response.NextCursor
+	}
+
+	expected := []uint{events[3].ID, events[2].ID, events[1].ID, events[0].ID, events[4].ID}
+	if !reflect.DeepEqual(ids, expected) {
+		t.Errorf("Expected events %v across pages, got %v", expected, ids)
+	}
+}
@@ -0,0 +1,287 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/takedown-observer/backend/models"
+	"github.com/takedown-observer/backend/validation"
+	"gorm.io/gorm"
+)
+
+// Export formats
+const (
+	formatCSV     = "csv"
+	formatJSONL   = "jsonl"
+	formatJSON    = "json"
+	formatParquet = "parquet"
+)
+
+// Maximum number of rows buffered in a single Parquet row group
+const parquetRowGroupSize = 50000
+
+// Number of rows written between flushes of a streamed download
+const exportFlushInterval = 500
+
+// exportFormat describes the encoding of a download format
+type exportFormat struct {
+	ContentType string
+	Extension   string
+}
+
+var exportFormats = map[string]exportFormat{
+	formatCSV:     {ContentType: "text/csv", Extension: "csv"},
+	formatJSONL:   {ContentType: "application/x-ndjson", Extension: "jsonl"},
+	formatJSON:    {ContentType: "application/json", Extension: "json"},
+	formatParquet: {ContentType: "application/vnd.apache.parquet", Extension: "parquet"},
+}
+
+// parseExportFormat parses the format query parameter of a download, which
+// defaults to CSV
+func parseExportFormat(params url.Values) (string, error) {
+	format := params.Get("format")
+	if format == "" {
+		return formatCSV, nil
+	}
+	return format, validation.ValidateOneOf("format", format, formatCSV, formatJSONL, formatJSON, formatParquet)
+}
+
+// exportSchema describes how rows of type T are exported: as CSV records,
+// and as typed records of type E in the other formats
+type exportSchema[T, E any] struct {
+	Filename  string
+	CSVHeader []string
+	CSVRecord func(T) []string
+	Export    func(T) E
+}
+
+// newWriter returns a writer encoding rows in the given format
+func (s exportSchema[T, E]) newWriter(format string, w io.Writer) rowWriter[T] {
+	switch format {
+	case formatJSONL:
+		return &jsonlWriter[T, E]{encoder: json.NewEncoder(w), export: s.Export}
+	case formatJSON:
+		return &jsonWriter[T, E]{w: w, export: s.Export}
+	case formatParquet:
+		return &parquetWriter[T, E]{
+			writer: parquet.NewGenericWriter[E](w, parquet.MaxRowsPerRowGroup(parquetRowGroupSize)),
+			export: s.Export,
+		}
+	default:
+		return &csvWriter[T]{writer: csv.NewWriter(w), header: s.CSVHeader, record: s.CSVRecord}
+	}
+}
+
+// streamDownload writes the rows of a query as a download in the given
+// format without loading them all into memory
+func streamDownload[T, E any](w http.ResponseWriter, r *http.Request, db *gorm.DB, query *gorm.DB, schema exportSchema[T, E], formatName string) {
+	format := exportFormats[formatName]
+
+	rows, err := query.Rows()
+	if err != nil {
+		writeDatabaseError(w, r)
+		return
+	}
+	defer rows.Close()
+
+	// Set headers for download
+	w.Header().Set("Content-Type", format.ContentType)
+	w.Header().Set("Content-Disposition", "attachment; filename="+schema.Filename+"."+format.Extension)
+
+	writer := schema.newWriter(formatName, w)
+	flusher, _ := w.(http.Flusher)
+
+	if err := writer.Start(); err != nil {
+		WriteProblem(w, r, http.StatusInternalServerError, codeInternalError, "", "Error writing download")
+		return
+	}
+
+	// Write data. Once rows have been sent the status can no longer be
+	// changed, so failures are logged and the download is truncated.
+	written := 0
+	for rows.Next() {
+		var row T
+		if err := db.ScanRows(rows, &row); err != nil {
+			log.Printf("Error reading row for download: %v", err)
+			return
+		}
+
+		if err := writer.Write(row); err != nil {
+			log.Printf("Error writing download: %v", err)
+			return
+		}
+
+		written++
+		if written%exportFlushInterval == 0 {
+			if err := writer.Flush(); err != nil {
+				log.Printf("Error writing download: %v", err)
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Error reading rows for download: %v", err)
+		return
+	}
+
+	if err := writer.Close(); err != nil {
+		log.Printf("Error writing download: %v", err)
+		return
+	}
+}
+
+// rowWriter encodes a stream of rows in an export format
+type rowWriter[T any] interface {
+	// Start writes anything that precedes the first row
+	Start() error
+	// Write encodes a single row
+	Write(row T) error
+	// Flush writes any buffered data to the underlying writer
+	Flush() error
+	// Close writes anything that follows the last row
+	Close() error
+}
+
+// accountExportSchema exports accounts. The CSV columns predate the typed
+// formats, so new columns are appended to keep existing consumers working.
+var accountExportSchema = exportSchema[models.Account, models.AccountExport]{
+	Filename:  "takedowns",
+	CSVHeader: []string{"Account ID", "Username", "Countries", "Last Reported At", "Data Format Version", "Platform"},
+	CSVRecord: func(account models.Account) []string {
+		return []string{
+			account.ID,
+			account.Name,
+			// Join countries array with commas
+			strings.Join(account.Countries, ", "),
+			account.LastReportedAt.Format(time.RFC3339),
+			account.DataFormatVersion,
+			account.Platform,
+		}
+	},
+	Export: exportAccount,
+}
+
+// exportAccount converts an account to its typed export representation
+func exportAccount(account models.Account) models.AccountExport {
+	countries := account.Countries
+	if countries == nil {
+		countries = []string{}
+	}
+
+	return models.AccountExport{
+		Platform:          account.Platform,
+		ID:                account.ID,
+		Name:              account.Name,
+		Countries:         countries,
+		ReportCount:       int64(account.ReportCount),
+		FirstSeenAt:       account.FirstSeenAt.UTC(),
+		LastReportedAt:    account.LastReportedAt.UTC(),
+		DataFormatVersion: account.DataFormatVersion,
+	}
+}
+
+// csvWriter writes rows as CSV records
+type csvWriter[T any] struct {
+	writer *csv.Writer
+	header []string
+	record func(T) []string
+}
+
+func (c *csvWriter[T]) Start() error {
+	return c.writer.Write(c.header)
+}
+
+func (c *csvWriter[T]) Write(row T) error {
+	return c.writer.Write(c.record(row))
+}
+
+func (c *csvWriter[T]) Flush() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+func (c *csvWriter[T]) Close() error {
+	return c.Flush()
+}
+
+// jsonlWriter writes one JSON object per line
+type jsonlWriter[T, E any] struct {
+	encoder *json.Encoder
+	export  func(T) E
+}
+
+func (j *jsonlWriter[T, E]) Start() error { return nil }
+
+func (j *jsonlWriter[T, E]) Write(row T) error {
+	return j.encoder.Encode(j.export(row))
+}
+
+func (j *jsonlWriter[T, E]) Flush() error { return nil }
+
+func (j *jsonlWriter[T, E]) Close() error { return nil }
+
+// jsonWriter writes a single JSON array, one element at a time
+type jsonWriter[T, E any] struct {
+	w       io.Writer
+	export  func(T) E
+	written bool
+}
+
+func (j *jsonWriter[T, E]) Start() error {
+	_, err := io.WriteString(j.w, "[")
+	return err
+}
+
+func (j *jsonWriter[T, E]) Write(row T) error {
+	if j.written {
+		if _, err := io.WriteString(j.w, ","); err != nil {
+			return err
+		}
+	}
+	j.written = true
+
+	data, err := json.Marshal(j.export(row))
+	if err != nil {
+		return err
+	}
+	_, err = j.w.Write(data)
+	return err
+}
+
+func (j *jsonWriter[T, E]) Flush() error { return nil }
+
+func (j *jsonWriter[T, E]) Close() error {
+	_, err := io.WriteString(j.w, "]\n")
+	return err
+}
+
+// parquetWriter writes rows as a Parquet file. Row groups are flushed as
+// they fill up; the file footer is written on Close.
+type parquetWriter[T, E any] struct {
+	writer *parquet.GenericWriter[E]
+	export func(T) E
+}
+
+func (p *parquetWriter[T, E]) Start() error { return nil }
+
+func (p *parquetWriter[T, E]) Write(row T) error {
+	_, err := p.writer.Write([]E{p.export(row)})
+	return err
+}
+
+func (p *parquetWriter[T, E]) Flush() error { return nil }
+
+func (p *parquetWriter[T, E]) Close() error {
+	return p.writer.Close()
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/takedown-observer/backend/models"
+	"gorm.io/gorm"
+)
+
+func TestDownloadHandlerFormats(t *testing.T) {
+	firstSeen := time.Date(2025, 2, 18, 9, 0, 0, 0, time.UTC)
+	lastReported := time.Date(2025, 2, 20, 14, 0, 0, 0, time.UTC)
+
+	setupDB := func(t *testing.T, db *gorm.DB) error {
+		account := models.Account{
+			ID:                "account1",
+			Name:              "Account1",
+			Countries:         []string{"DE", "FR"},
+			FirstSeenAt:       firstSeen,
+			LastReportedAt:    lastReported,
+			ReportCount:       3,
+			DataFormatVersion: "1.0",
+		}
+		return db.Create(&account).Error
+	}
+
+	expected := models.AccountExport{
+		Platform:          "x",
+		ID:                "account1",
+		Name:              "Account1",
+		Countries:         []string{"DE", "FR"},
+		ReportCount:       3,
+		FirstSeenAt:       firstSeen,
+		LastReportedAt:    lastReported,
+		DataFormatVersion: "1.0",
+	}
+
+	checkExport := func(t *testing.T, exports []models.AccountExport) {
+		if len(exports) != 1 {
+			t.Fatalf("Expected 1 exported account, got %d", len(exports))
+		}
+		got := exports[0]
+		if !got.FirstSeenAt.Equal(expected.FirstSeenAt) || !got.LastReportedAt.Equal(expected.LastReportedAt) {
+			t.Errorf("Expected timestamps %v/%v, got %v/%v", expected.FirstSeenAt, expected.LastReportedAt, got.FirstSeenAt, got.LastReportedAt)
+		}
+		got.FirstSeenAt, got.LastReportedAt = expected.FirstSeenAt, expected.LastReportedAt
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("Expected export %+v, got %+v", expected, got)
+		}
+	}
+
+	tests := []struct {
+		name                string
+		format              string
+		expectedCode        int
+		expectedContentType string
+		expectedFilename    string
+		checkBody           func(*testing.T, []byte)
+	}{
+		{
+			name:                "JSON Lines",
+			format:              "jsonl",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "application/x-ndjson",
+			expectedFilename:    "takedowns.jsonl",
+			checkBody: func(t *testing.T, body []byte) {
+				var exports []models.AccountExport
+				scanner := bufio.NewScanner(bytes.NewReader(body))
+				for scanner.Scan() {
+					var export models.AccountExport
+					if err := json.Unmarshal(scanner.Bytes(), &export); err != nil {
+						t.Fatalf("Failed to decode line %q: %v", scanner.Text(), err)
+					}
+					exports = append(exports, export)
+				}
+				checkExport(t, exports)
+			},
+		},
+		{
+			name:                "JSON",
+			format:              "json",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "application/json",
+			expectedFilename:    "takedowns.json",
+			checkBody: func(t *testing.T, body []byte) {
+				var exports []models.AccountExport
+				if err := json.Unmarshal(body, &exports); err != nil {
+					t.Fatalf("Failed to decode JSON array: %v", err)
+				}
+				checkExport(t, exports)
+			},
+		},
+		{
+			name:                "Parquet",
+			format:              "parquet",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "application/vnd.apache.parquet",
+			expectedFilename:    "takedowns.parquet",
+			checkBody: func(t *testing.T, body []byte) {
+				exports, err := parquet.Read[models.AccountExport](bytes.NewReader(body), int64(len(body)))
+				if err != nil {
+					t.Fatalf("Failed to read Parquet file: %v", err)
+				}
+				checkExport(t, exports)
+			},
+		},
+		{
+			name:                "CSV by default",
+			format:              "",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "text/csv",
+			expectedFilename:    "takedowns.csv",
+		},
+		{
+			name:         "unknown format",
+			format:       "xml",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+
+			if err := setupDB(t, db); err != nil {
+				t.Fatalf("Failed to setup test database: %v", err)
+			}
+
+			handler := NewHandler(db)
+
+			req := httptest.NewRequest("GET", "/api/download?format="+tt.format, nil)
+			w := httptest.NewRecorder()
+
+			handler.DownloadHandler(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("DownloadHandler() status code = %v, want %v", w.Code, tt.expectedCode)
+			}
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+
+			if ct := w.Header().Get("Content-Type"); ct != tt.expectedContentType {
+				t.Errorf("Expected Content-Type %s, got %s", tt.expectedContentType, ct)
+			}
+			if cd := w.Header().Get("Content-Disposition"); !strings.HasSuffix(cd, "filename="+tt.expectedFilename) {
+				t.Errorf("Expected filename %s, got %s", tt.expectedFilename, cd)
+			}
+
+			if tt.checkBody != nil {
+				tt.checkBody(t, w.Body.Bytes())
+			}
+		})
+	}
+}
+
+func TestDownloadHandlerEmptyJSON(t *testing.T) {
+	handler := NewHandler(newTestDB(t))
+
+	req := httptest.NewRequest("GET", "/api/download?format=json", nil)
+	w := httptest.NewRecorder()
+	handler.DownloadHandler(w, req)
+
+	var exports []models.AccountExport
+	if err := json.Unmarshal(w.Body.Bytes(), &exports); err != nil {
+		t.Fatalf("Failed to decode JSON array %q: %v", w.Body.String(), err)
+	}
+	if len(exports) != 0 {
+		t.Errorf("Expected empty array, got %v", exports)
+	}
+}
@@ -0,0 +1,349 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/takedown-observer/backend/models"
+	"github.com/takedown-observer/backend/validation"
+	"gorm.io/gorm"
+)
+
+// Country filter match modes
+const (
+	matchAny = "any"
+	matchAll = "all"
+)
+
+// Sortable account columns
+const (
+	sortLastReportedAt = "last_reported_at"
+	sortFirstSeenAt    = "first_seen_at"
+	sortReportCount    = "report_count"
+	sortName           = "name"
+	sortRelevance      = "relevance"
+)
+
+// Sort orders
+const (
+	orderAsc  = "asc"
+	orderDesc = "desc"
+)
+
+// accountFilters holds the account filters accepted by the listing
+type accountFilters struct {
+	Platform          string
+	Reasons           []string
+	Countries         []string
+	Match             string
+	ExcludedCountries []string
+	Search            string
+	Fuzzy             bool
+	ReportedAfter     *time.Time
+	ReportedBefore    *time.Time
+	MinReports        int
+	MinCountries      int
+	MinReporters      int
+}
+
+// parseAccountFilters parses and validates account filters from query
+// parameters
+func parseAccountFilters(params url.Values) (accountFilters, error) {
+	var filters accountFilters
+	var err error
+
+	filters.Search = strings.TrimSpace(params.Get("search"))
+
+	if filters.Platform = params.Get("platform"); filters.Platform != "" {
+		if err := validation.ValidatePlatform(filters.Platform); err != nil {
+			return filters, err
+		}
+	}
+
+	if filters.Reasons, err = validation.ParseReasonList(params.Get("reason")); err != nil {
+		return filters, err
+	}
+
+	if value := params.Get("fuzzy"); value != "" {
+		fuzzy, err := strconv.ParseBool(value)
+		if err != nil {
+			return filters, validation.Errorf("fuzzy", validation.CodeInvalidFormat, "fuzzy must be true or false")
+		}
+		filters.Fuzzy = fuzzy
+	}
+
+	if filters.Countries, err = validation.ParseCountryList("country", params.Get("country")); err != nil {
+		return filters, err
+	}
+
+	if filters.ExcludedCountries, err = validation.ParseCountryList("exclude_country", params.Get("exclude_country")); err != nil {
+		return filters, err
+	}
+
+	filters.Match = params.Get("match")
+	if filters.Match == "" {
+		filters.Match = matchAny
+	}
+	if err := validation.ValidateOneOf("match", filters.Match, matchAny, matchAll); err != nil {
+		return filters, err
+	}
+
+	if value := params.Get("reported_after"); value != "" {
+		reportedAfter, err := validation.ParseTimestamp("reported_after", value)
+		if err != nil {
+			return filters, err
+		}
+		filters.ReportedAfter = &reportedAfter
+	}
+
+	if value := params.Get("reported_before"); value != "" {
+		reportedBefore, err := validation.ParseTimestamp("reported_before", value)
+		if err != nil {
+			return filters, err
+		}
+		filters.ReportedBefore = &reportedBefore
+	}
+
+	if filters.ReportedAfter != nil && filters.ReportedBefore != nil {
+		if err := validation.ValidateTimeRange(*filters.ReportedAfter, *filters.ReportedBefore); err != nil {
+			return filters, err
+		}
+	}
+
+	if filters.MinReports, err = validation.ParseCount("min_reports", params.Get("min_reports")); err != nil {
+		return filters, err
+	}
+
+	if filters.MinCountries, err = validation.ParseCount("min_countries", params.Get("min_countries")); err != nil {
+		return filters, err
+	}
+
+	if filters.MinReporters, err = validation.ParseCount("min_reporters", params.Get("min_reporters")); err != nil {
+		return filters, err
+	}
+
+	return filters, nil
+}
+
+// confirmedCountries returns a query over the country join table limited to
+// countries confirmed by at least MinReporters clients. Country filters only
+// consider these countries, so single-source claims can be ignored.
+func (f accountFilters) confirmedCountries(db *gorm.DB) *gorm.DB {
+	query := db.Model(&models.AccountCountry{}).Select("account_countries.platform, account_countries.account_id")
+	if f.MinReporters > 1 {
+		query = query.Where(`(SELECT COUNT(*) FROM country_confirmations
+			WHERE country_confirmations.platform = account_countries.platform
+			AND country_confirmations.account_id = account_countries.account_id
+			AND country_confirmations.country_code = account_countries.country_code) +
+			COALESCE((SELECT count FROM purged_confirmations
+			WHERE purged_confirmations.platform = account_countries.platform
+			AND purged_confirmations.account_id = account_countries.account_id
+			AND purged_confirmations.country_code = account_countries.country_code), 0) >= ?`, f.MinReporters)
+	}
+	return query
+}
+
+// usesFullText reports whether the search filter is answered from the
+// full-text index, which requires FTS5 and a term of at least one trigram
+func (f accountFilters) usesFullText(fullTextSearch bool) bool {
+	return fullTextSearch && utf8.RuneCountInString(f.Search) >= 3
+}
+
+// apply adds the filters to an account query. Subqueries select accounts
+// by platform and ID.
+func (f accountFilters) apply(db *gorm.DB, query *gorm.DB, fullTextSearch bool) *gorm.DB {
+	if f.Platform != "" {
+		query = query.Where("accounts.platform = ?", f.Platform)
+	}
+
+	if len(f.Reasons) > 0 {
+		query = query.Where("accounts.reason IN ?", f.Reasons)
+	}
+
+	if len(f.Countries) > 0 {
+		if f.Match == matchAll {
+			query = query.Where("(accounts.platform, accounts.id) IN (?)", f.confirmedCountries(db).
+				Where("account_countries.country_code IN ?", f.Countries).
+				Group("account_countries.platform, account_countries.account_id").
+				Having("COUNT(*) = ?", len(f.Countries)))
+		} else {
+			query = query.Where("(accounts.platform, accounts.id) IN (?)", f.confirmedCountries(db).
+				Where("account_countries.country_code IN ?", f.Countries))
+		}
+	} else if f.MinReporters > 1 {
+		query = query.Where("(accounts.platform, accounts.id) IN (?)", f.confirmedCountries(db))
+	}
+
+	if len(f.ExcludedCountries) > 0 {
+		query = query.Where("(accounts.platform, accounts.id) NOT IN (?)", f.confirmedCountries(db).
+			Where("account_countries.country_code IN ?", f.ExcludedCountries))
+	}
+
+	if f.usesFullText(fullTextSearch) {
+		query = query.Joins("JOIN "+models.AccountSearchTable+" ON "+models.AccountSearchTable+".rowid = accounts.rowid").
+			Where(models.AccountSearchTable+" MATCH ?", searchExpression(f.Search, f.Fuzzy))
+	} else if f.Search != "" {
+		pattern := "%" + f.Search + "%"
+		query = query.Where("accounts.name LIKE ? OR accounts.id LIKE ? OR (accounts.platform, accounts.id) IN (?)", pattern, pattern,
+			db.Model(&models.AccountName{}).Select("platform, account_id").Where("name LIKE ?", pattern))
+	}
+
+	if f.ReportedAfter != nil {
+		query = query.Where("accounts.last_reported_at >= ?", f.ReportedAfter.UTC())
+	}
+
+	if f.ReportedBefore != nil {
+		query = query.Where("accounts.last_reported_at < ?", f.ReportedBefore.UTC())
+	}
+
+	if f.MinReports > 0 {
+		query = query.Where("accounts.report_count >= ?", f.MinReports)
+	}
+
+	if f.MinCountries > 0 {
+		query = query.Where("(?) >= ?", f.confirmedCountries(db).
+			Select("COUNT(*)").
+			Where("account_countries.platform = accounts.platform AND account_countries.account_id = accounts.id"), f.MinCountries)
+	}
+
+	return query
+}
+
+// reasonCounts counts the accounts matching the filters by withholding
+// reason. The reason filter itself is left out, so clients can show how many
+// accounts selecting another reason would match.
+func (f accountFilters) reasonCounts(db *gorm.DB, fullTextSearch bool) ([]models.ReasonCount, error) {
+	f.Reasons = nil
+
+	reasons := []models.ReasonCount{}
+	err := f.apply(db, db.Model(&models.Account{}), fullTextSearch).
+		Select("accounts.reason AS reason, COUNT(*) AS count").
+		Group("accounts.reason").
+		Order("count desc, reason asc").
+		Scan(&reasons).Error
+	return reasons, err
+}
+
+// listingSort describes the order of an account or post listing. Ties are
+// broken by platform and ID in the same direction so the order is total.
+type listingSort struct {
+	Table  string
+	Column string
+	Order  string
+}
+
+// parseSort parses and validates the sort and order query parameters
+// against the sortable columns, starting from the default order
+func parseSort(params url.Values, sort listingSort, columns ...string) (listingSort, error) {
+	if value := params.Get("sort"); value != "" {
+		if err := validation.ValidateOneOf("sort", value, columns...); err != nil {
+			return sort, err
+		}
+		sort.Column = value
+	}
+
+	if value := params.Get("order"); value != "" {
+		if err := validation.ValidateOneOf("order", value, orderAsc, orderDesc); err != nil {
+			return sort, err
+		}
+		sort.Order = value
+	}
+
+	return sort, nil
+}
+
+// parseAccountSort parses and validates the sort and order query parameters.
+// Results are sorted by relevance when searching with the full-text index,
+// which is otherwise unavailable.
+func parseAccountSort(params url.Values, filters accountFilters, fullTextSearch bool) (listingSort, error) {
+	sort := listingSort{Table: "accounts", Column: sortLastReportedAt, Order: orderDesc}
+	if filters.usesFullText(fullTextSearch) {
+		sort.Column = sortRelevance
+	}
+
+	sort, err := parseSort(params, sort, sortLastReportedAt, sortFirstSeenAt, sortReportCount, sortName, sortRelevance)
+	if err != nil {
+		return sort, err
+	}
+	if sort.Column == sortRelevance && !filters.usesFullText(fullTextSearch) {
+		return sort, validation.Errorf("sort", validation.CodeNotAllowed, "sort by relevance requires a search term of at least 3 characters")
+	}
+
+	return sort, nil
+}
+
+// apply orders a listing query. Relevance ordering also selects the search
+// score, so it requires the full-text search filter.
+func (s listingSort) apply(query *gorm.DB) *gorm.DB {
+	if s.Column == sortRelevance {
+		return query.Select(s.Table + ".*, -bm25(" + models.AccountSearchTable + ") AS score").
+			Order(fmt.Sprintf("score %[2]s, %[1]s.platform %[2]s, %[1]s.id %[2]s", s.Table, s.Order))
+	}
+
+	// Table, column and order are whitelisted by the parsers. Columns are
+	// selected explicitly since the score only exists in relevance order.
+	return query.Select(s.Table + ".*").
+		Order(fmt.Sprintf("%[1]s.%[2]s %[3]s, %[1]s.platform %[3]s, %[1]s.id %[3]s", s.Table, s.Column, s.Order))
+}
+
+// supportsCursor reports whether listings in this order can be paged with
+// cursors. Relevance scores change as the index grows, so they cannot.
+func (s listingSort) supportsCursor() bool {
+	return s.Column != sortRelevance
+}
+
+// after restricts a listing query to the rows following the cursor
+func (s listingSort) after(query *gorm.DB, cursor listingCursor) (*gorm.DB, error) {
+	if !s.supportsCursor() {
+		return nil, validation.Errorf("cursor", validation.CodeNotAllowed, "Cursor pagination is not available when sorting by relevance")
+	}
+
+	if cursor.Sort != s.Column+" "+s.Order {
+		return nil, validation.Errorf("cursor", validation.CodeNotAllowed, "Cursor does not match sort order")
+	}
+
+	value, err := s.parseValue(cursor.Value)
+	if err != nil {
+		return nil, validation.Errorf("cursor", validation.CodeInvalidFormat, "Invalid cursor")
+	}
+
+	op := "<"
+	if s.Order == orderAsc {
+		op = ">"
+	}
+
+	return query.Where(fmt.Sprintf("%[1]s.%[2]s %[3]s ? OR (%[1]s.%[2]s = ? AND (%[1]s.platform, %[1]s.id) %[3]s (?, ?))", s.Table, s.Column, op),
+		value, value, cursor.Platform, cursor.ID), nil
+}
+
+// value returns the sort column value of a listed row in cursor form. Posts
+// have no name, since they cannot be sorted by it.
+func (s listingSort) value(lastReportedAt, firstSeenAt time.Time, reportCount int, name string) string {
+	switch s.Column {
+	case sortFirstSeenAt:
+		return firstSeenAt.UTC().Format(time.RFC3339Nano)
+	case sortReportCount:
+		return strconv.Itoa(reportCount)
+	case sortName:
+		return name
+	default:
+		return lastReportedAt.UTC().Format(time.RFC3339Nano)
+	}
+}
+
+// parseValue converts a cursor value back to the type of the sort column
+func (s listingSort) parseValue(value string) (interface{}, error) {
+	switch s.Column {
+	case sortReportCount:
+		return strconv.Atoi(value)
+	case sortName:
+		return value, nil
+	default:
+		t, err := time.Parse(time.RFC3339Nano, value)
+		return t.UTC(), err
+	}
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/takedown-observer/backend/models"
+	"github.com/takedown-observer/backend/validation"
+)
+
+// dataFormat is a supported version of the report payload format. Its
+// decoders upgrade payloads to the current request models, so handlers only
+// deal with the current format.
+type dataFormat struct {
+	Version      string
+	DeprecatedAt *time.Time
+	SunsetAt     *time.Time
+
+	decodeReport     func(body []byte) (models.ReportRequest, error)
+	decodePostReport func(body []byte) (models.PostReportRequest, error)
+}
+
+// dataFormats lists the supported data format versions, newest first. A
+// version is only added when the payload format changes, along with a
+// decoder upgrading payloads of the version it replaces. Deprecation and
+// sunset dates are set by the release that deprecates a version, and
+// versions are removed once their sunset has passed.
+var dataFormats = []dataFormat{
+	{
+		Version:          models.DataFormatVersion,
+		decodeReport:     decodeJSON[models.ReportRequest],
+		decodePostReport: decodeJSON[models.PostReportRequest],
+	},
+}
+
+var (
+	errInvalidBody       = validation.Errorf("", codeInvalidBody, "Invalid request body")
+	errUnsupportedFormat = validation.Errorf("data_format_version", codeUnsupportedFormat, "Unsupported data format version")
+)
+
+// lookupDataFormat returns the format of a request body from its
+// data_format_version field
+func lookupDataFormat(body []byte) (dataFormat, error) {
+	var envelope struct {
+		DataFormatVersion string `json:"data_format_version"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return dataFormat{}, errInvalidBody
+	}
+
+	for _, format := range dataFormats {
+		if format.Version == envelope.DataFormatVersion {
+			return format, nil
+		}
+	}
+	return dataFormat{}, errUnsupportedFormat
+}
+
+// decodeReport decodes an account report in any supported format
+func decodeReport(body []byte) (dataFormat, models.ReportRequest, error) {
+	format, err := lookupDataFormat(body)
+	if err != nil {
+		return format, models.ReportRequest{}, err
+	}
+
+	report, err := format.decodeReport(body)
+	if err != nil {
+		return format, models.ReportRequest{}, errInvalidBody
+	}
+	return format, report, nil
+}
+
+// decodePostReport decodes a post report in any supported format
+func decodePostReport(body []byte) (dataFormat, models.PostReportRequest, error) {
+	format, err := lookupDataFormat(body)
+	if err != nil {
+		return format, models.PostReportRequest{}, err
+	}
+
+	report, err := format.decodePostReport(body)
+	if err != nil {
+		return format, models.PostReportRequest{}, errInvalidBody
+	}
+	return format, report, nil
+}
+
+// decodeJSON decodes a payload that is already in the current format
+func decodeJSON[T any](body []byte) (T, error) {
+	var value T
+	err := json.Unmarshal(body, &value)
+	return value, err
+}
+
+// setDeprecationHeaders tells clients using a deprecated format when it was
+// deprecated (RFC 9745) and when it stops being accepted (RFC 8594), and
+// links to the list of supported formats
+func setDeprecationHeaders(w http.ResponseWriter, format dataFormat) {
+	if format.DeprecatedAt == nil {
+		return
+	}
+
+	w.Header().Set("Deprecation", "@"+strconv.FormatInt(format.DeprecatedAt.Unix(), 10))
+	if format.SunsetAt != nil {
+		w.Header().Set("Sunset", format.SunsetAt.UTC().Format(http.TimeFormat))
+	}
+	w.Header().Set("Link", `</api/formats>; rel="deprecation"`)
+}
+
+// GetFormatsHandler handles GET /api/formats
+func (h *Handler) GetFormatsHandler(w http.ResponseWriter, r *http.Request) {
+	formats := make([]models.DataFormat, len(dataFormats))
+	for i, format := range dataFormats {
+		formats[i] = models.DataFormat{
+			Version:      format.Version,
+			Current:      format.Version == models.DataFormatVersion,
+			DeprecatedAt: format.DeprecatedAt,
+			SunsetAt:     format.SunsetAt,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.FormatsResponse{
+		Current: models.DataFormatVersion,
+		Formats: formats,
+	})
+}
@@ -0,0 +1,200 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/takedown-observer/backend/models"
+)
+
+// legacyReportRequest is the account report of a test format that predates
+// the current one, with account fields at the top level
+type legacyReportRequest struct {
+	ClientID          string   `json:"client_id"`
+	AccountID         string   `json:"account_id"`
+	AccountName       string   `json:"account_name"`
+	Countries         []string `json:"countries"`
+	DataFormatVersion string   `json:"data_format_version"`
+}
+
+// withLegacyFormat registers a deprecated test format 0.9 for the duration
+// of a test
+func withLegacyFormat(t *testing.T) {
+	t.Helper()
+
+	deprecatedAt := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	sunsetAt := time.Date(2026, time.July, 1, 0, 0, 0, 0, time.UTC)
+	previous := dataFormats
+	dataFormats = append(append([]dataFormat{}, previous...), dataFormat{
+		Version:      "0.9",
+		DeprecatedAt: &deprecatedAt,
+		SunsetAt:     &sunsetAt,
+		decodeReport: func(body []byte) (models.ReportRequest, error) {
+			var report legacyReportRequest
+			if err := json.Unmarshal(body, &report); err != nil {
+				return models.ReportRequest{}, err
+			}
+			return models.ReportRequest{
+				ClientID: report.ClientID,
+				Account: models.ReportedAccount{
+					ID:        report.AccountID,
+					Name:      report.AccountName,
+					Countries: report.Countries,
+				},
+				DataFormatVersion: report.DataFormatVersion,
+			}, nil
+		},
+		decodePostReport: decodeJSON[models.PostReportRequest],
+	})
+	t.Cleanup(func() { dataFormats = previous })
+}
+
+func TestDecodeReport(t *testing.T) {
+	withLegacyFormat(t)
+
+	tests := []struct {
+		name             string
+		body             string
+		expectedErr      error
+		expectedID       string
+		expectedPlatform string
+		expectedReason   string
+	}{
+		{
+			name:             "current format",
+			body:             `{"client_id":"123e4567-e89b-12d3-a456-426614174000","account":{"platform":"youtube","id":"UCabcdefghijklmnopqrstuv","name":"Channel","countries":["DE"],"reason":"local_laws"},"data_format_version":"1.0"}`,
+			expectedID:       "UCabcdefghijklmnopqrstuv",
+			expectedPlatform: "youtube",
+			expectedReason:   "local_laws",
+		},
+		{
+			name:       "older formats are upgraded",
+			body:       `{"client_id":"123e4567-e89b-12d3-a456-426614174000","account_id":"1234567890","account_name":"TestAccount","countries":["DE"],"data_format_version":"0.9"}`,
+			expectedID: "1234567890",
+		},
+		{
+			name:        "unsupported format",
+			body:        `{"client_id":"123e4567-e89b-12d3-a456-426614174000","data_format_version":"0.8"}`,
+			expectedErr: errUnsupportedFormat,
+		},
+		{
+			name:        "missing format",
+			body:        `{"client_id":"123e4567-e89b-12d3-a456-426614174000"}`,
+			expectedErr: errUnsupportedFormat,
+		},
+		{
+			name:        "invalid JSON",
+			body:        `{"client_id":`,
+			expectedErr: errInvalidBody,
+		},
+		{
+			name:        "invalid payload for format",
+			body:        `{"account":{"countries":"DE"},"data_format_version":"1.0"}`,
+			expectedErr: errInvalidBody,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, report, err := decodeReport([]byte(tt.body))
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("decodeReport() error = %v, want %v", err, tt.expectedErr)
+			}
+			if err != nil {
+				return
+			}
+			if report.Account.ID != tt.expectedID || report.Account.Platform != tt.expectedPlatform || report.Account.Reason != tt.expectedReason {
+				t.Errorf("decodeReport() id = %q, platform = %q, reason = %q, want %q, %q and %q",
+					report.Account.ID, report.Account.Platform, report.Account.Reason, tt.expectedID, tt.expectedPlatform, tt.expectedReason)
+			}
+		})
+	}
+}
+
+func TestReportHandlerDeprecationHeaders(t *testing.T) {
+	withLegacyFormat(t)
+	handler := NewHandler(newTestDB(t))
+
+	bodies := map[string]string{
+		models.DataFormatVersion: `{"client_id":"123e4567-e89b-12d3-a456-426614174000","account":{"id":"test_account","name":"TestAccount","countries":["DE"]},"data_format_version":"1.0"}`,
+		"0.9":                    `{"client_id":"123e4567-e89b-12d3-a456-426614174000","account_id":"test_account","account_name":"TestAccount","countries":["DE"],"data_format_version":"0.9"}`,
+	}
+
+	for version, body := range bodies {
+		t.Run(version, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/report", bytes.NewBufferString(body))
+			w := httptest.NewRecorder()
+			handler.ReportHandler(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("ReportHandler() status code = %v (%s)", w.Code, w.Body.String())
+			}
+
+			deprecated := version != models.DataFormatVersion
+			if (w.Header().Get("Deprecation") != "") != deprecated {
+				t.Errorf("Expected Deprecation header %v, got %q", deprecated, w.Header().Get("Deprecation"))
+			}
+			if !deprecated {
+				return
+			}
+			if w.Header().Get("Deprecation") != "@1767225600" {
+				t.Errorf("Unexpected Deprecation header %q", w.Header().Get("Deprecation"))
+			}
+			if w.Header().Get("Sunset") != "Wed, 01 Jul 2026 00:00:00 GMT" {
+				t.Errorf("Unexpected Sunset header %q", w.Header().Get("Sunset"))
+			}
+			if w.Header().Get("Link") != `</api/formats>; rel="deprecation"` {
+				t.Errorf("Unexpected Link header %q", w.Header().Get("Link"))
+			}
+		})
+	}
+
+	t.Run("unsupported format", func(t *testing.T) {
+		body := `{"client_id":"123e4567-e89b-12d3-a456-426614174000","account":{"id":"test_account","name":"TestAccount","countries":["DE"]},"data_format_version":"2.0"}`
+		req := httptest.NewRequest("POST", "/api/report", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		handler.ReportHandler(w, req)
+
+		if w.Code != http.StatusBadRequest || !bytes.Contains(w.Body.Bytes(), []byte("Unsupported data format version")) {
+			t.Errorf("ReportHandler() = %v %s, want unsupported format error", w.Code, w.Body.String())
+		}
+	})
+}
+
+func TestGetFormatsHandler(t *testing.T) {
+	handler := NewHandler(newTestDB(t))
+
+	fetch := func() models.FormatsResponse {
+		req := httptest.NewRequest("GET", "/api/formats", nil)
+		w := httptest.NewRecorder()
+		handler.GetFormatsHandler(w, req)
+
+		var response models.FormatsResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return response
+	}
+
+	response := fetch()
+	if response.Current != models.DataFormatVersion {
+		t.Errorf("Expected current version %s, got %s", models.DataFormatVersion, response.Current)
+	}
+	if len(response.Formats) != 1 || !response.Formats[0].Current || response.Formats[0].DeprecatedAt != nil {
+		t.Fatalf("Expected only the current format, got %+v", response.Formats)
+	}
+
+	withLegacyFormat(t)
+	response = fetch()
+	if len(response.Formats) != 2 {
+		t.Fatalf("Expected 2 formats, got %d", len(response.Formats))
+	}
+	if old := response.Formats[1]; old.Version != "0.9" || old.Current || old.DeprecatedAt == nil || old.SunsetAt == nil {
+		t.Errorf("Expected 0.9 to be deprecated, got %+v", old)
+	}
+}
@@ -1,70 +1,177 @@
 package api
 
 import (
-	"encoding/csv"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
-	"sort"
 	"strconv"
-	"strings"
 	"time"
 
+	"github.com/takedown-observer/backend/db"
 	"github.com/takedown-observer/backend/models"
 	"github.com/takedown-observer/backend/validation"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Handler struct {
-	db *gorm.DB
+	db             *gorm.DB
+	config         Config
+	countries      *countryCache
+	challenges     *challengeIssuer
+	fullTextSearch bool
 }
 
+// NewHandler creates a handler using the default configuration
 func NewHandler(db *gorm.DB) *Handler {
-	return &Handler{db: db}
+	return NewHandlerWithConfig(db, DefaultConfig())
+}
+
+// NewHandlerWithConfig creates a handler using the given configuration
+func NewHandlerWithConfig(db *gorm.DB, config Config) *Handler {
+	return &Handler{
+		db:             db,
+		config:         config,
+		countries:      &countryCache{},
+		challenges:     newChallengeIssuer(config),
+		fullTextSearch: db.Migrator().HasTable(models.AccountSearchTable),
+	}
+}
+
+// MaxBodySize returns the maximum size in bytes of a request body, which is
+// that of a full batch of reports
+func (h *Handler) MaxBodySize() int64 {
+	return int64(h.config.MaxBatchSize) * MaxReportSize
+}
+
+// ReadBody reads a request body of at most limit bytes. Larger bodies are
+// rejected with 413 Request Entity Too Large without being read in full.
+// If the body cannot be read, the problem is written and false is returned.
+func ReadBody(w http.ResponseWriter, r *http.Request, limit int64) ([]byte, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		WriteProblem(w, r, http.StatusRequestEntityTooLarge, codeBodyTooLarge, "",
+			fmt.Sprintf("Request body exceeds maximum of %d bytes", limit))
+		return nil, false
+	}
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidBody, errInvalidBody)
+		return nil, false
+	}
+	return body, true
+}
+
+// hashClientID returns the keyed hash of a client ID so that raw client IDs
+// are never stored
+func (h *Handler) hashClientID(clientID string) string {
+	return db.HashClientID(h.config.ClientHashKey, clientID)
 }
 
 // ReportHandler handles POST /api/report
 func (h *Handler) ReportHandler(w http.ResponseWriter, r *http.Request) {
-	var report models.ReportRequest
-	if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	// The raw body is kept since signatures are computed over it
+	body, ok := ReadBody(w, r, MaxReportSize)
+	if !ok {
 		return
 	}
 
-	// Validate client ID
-	if !validation.ValidateUUID(report.ClientID) {
-		http.Error(w, "Invalid client ID format", http.StatusBadRequest)
+	format, report, err := decodeReport(body)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err)
 		return
 	}
+	setDeprecationHeaders(w, format)
 
-	// Validate data format version
-	if report.DataFormatVersion != models.DataFormatVersion {
-		http.Error(w, "Unsupported data format version", http.StatusBadRequest)
+	sanitizedAccount, err := validateReport(report)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err)
 		return
 	}
 
-	// Validate and sanitize account data
-	if err := validation.ValidateAccountID(report.Account.ID); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	// Proof of work is checked first so that requests cannot make the
+	// server look up clients without paying for it
+	if err := h.checkProofOfWork(r, 1); err != nil {
+		writeError(w, r, http.StatusForbidden, codeProofOfWork, err)
 		return
 	}
 
-	if err := validation.ValidateAccountName(report.Account.Name); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	signer, verifyErr := h.verifySignature(r, body)
+	if err := h.checkSigned(signer, verifyErr, report.ClientID); err != nil {
+		writeError(w, r, http.StatusUnauthorized, codeUnauthorized, err)
 		return
 	}
 
-	if err := validation.ValidateCountries(report.Account.Countries); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	// Database transaction
+	var countriesChanged bool
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		countriesChanged, err = recordReport(tx, h.hashClientID(report.ClientID), sanitizedAccount)
+		return err
+	})
+	if err == nil && countriesChanged {
+		h.countries.invalidate()
+	}
+	h.challenges.record(1)
+
+	if err != nil {
+		writeDatabaseError(w, r)
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
+}
+
+// validateReport validates a report request decoded to the current format
+// and returns the sanitized account it describes
+func validateReport(report models.ReportRequest) (models.Account, error) {
+	// Validate client ID
+	if !validation.ValidateUUID(report.ClientID) {
+		return models.Account{}, validation.Errorf("client_id", validation.CodeInvalidFormat, "Invalid client ID format")
+	}
+
+	// Accounts are X accounts unless reported otherwise
+	platform := report.Account.Platform
+	if platform == "" {
+		platform = validation.DefaultPlatform
+	}
+
+	// Validate and sanitize account data against the platform's rules
+	if err := validation.ValidatePlatformAccount(platform, report.Account.ID, report.Account.Name); err != nil {
+		return models.Account{}, validation.Nest(err, "account")
+	}
+
+	if err := validation.ValidateCountries(report.Account.Countries); err != nil {
+		return models.Account{}, validation.Nest(err, "account")
+	}
+
+	// Reports from clients that do not read withholding notices have no
+	// reason
+	reason := report.Account.Reason
+	if reason == "" {
+		reason = validation.ReasonUnspecified
+	}
+	if err := validation.ValidateReason(reason); err != nil {
+		return models.Account{}, validation.Nest(err, "account")
+	}
+
+	if err := validation.ValidateNotice(report.Account.Notice); err != nil {
+		return models.Account{}, validation.Nest(err, "account")
+	}
+
 	// Sanitize input
 	sanitizedAccount := models.Account{
+		Platform:          platform,
 		ID:                validation.SanitizeString(report.Account.ID),
 		Name:              validation.SanitizeString(report.Account.Name),
 		Countries:         make([]string, len(report.Account.Countries)),
-		LastReportedAt:    time.Now(),
+		LastReportedAt:    time.Now().UTC(),
 		DataFormatVersion: report.DataFormatVersion,
+		Reason:            reason,
+		Notice:            validation.SanitizeString(report.Account.Notice),
 	}
 
 	// Sanitize countries
@@ -72,117 +179,213 @@ func (h *Handler) ReportHandler(w http.ResponseWriter, r *http.Request) {
 		sanitizedAccount.Countries[i] = validation.SanitizeString(country)
 	}
 
-	// Database transaction
-	err := h.db.Transaction(func(tx *gorm.DB) error {
-		var existingAccount models.Account
-		result := tx.First(&existingAccount, "id = ?", sanitizedAccount.ID)
-
-		if result.Error == gorm.ErrRecordNotFound {
-			// New account
-			sanitizedAccount.ReportCount = 1
-			sanitizedAccount.ReportedBy = []string{report.ClientID}
+	return sanitizedAccount, nil
+}
 
-			if err := tx.Create(&sanitizedAccount).Error; err != nil {
-				return err
-			}
-		} else if result.Error != nil {
-			return result.Error
-		} else {
-			// Update existing account
-			reported := false
-			for _, id := range existingAccount.ReportedBy {
-				if id == report.ClientID {
-					reported = true
-					break
-				}
-			}
+// recordReport stores a validated report from the client with the given
+// hash within the provided transaction. It returns whether the countries
+// of the account changed, which invalidates the cached country counts.
+func recordReport(tx *gorm.DB, clientHash string, sanitizedAccount models.Account) (bool, error) {
+	var existingAccount models.Account
+	var countriesChanged bool
+	result := tx.First(&existingAccount, "platform = ? AND id = ?", sanitizedAccount.Platform, sanitizedAccount.ID)
+
+	if result.Error == gorm.ErrRecordNotFound {
+		// New account
+		sanitizedAccount.ReportCount = 1
+		sanitizedAccount.FirstSeenAt = sanitizedAccount.LastReportedAt
+
+		if err := tx.Create(&sanitizedAccount).Error; err != nil {
+			return false, err
+		}
 
-			if !reported {
-				existingAccount.ReportCount++
-				existingAccount.ReportedBy = append(existingAccount.ReportedBy, report.ClientID)
-			}
+		if _, err := recordReporter(tx, sanitizedAccount.Platform, sanitizedAccount.ID, clientHash, sanitizedAccount.LastReportedAt); err != nil {
+			return false, err
+		}
 
-			existingAccount.Name = sanitizedAccount.Name
-			existingAccount.Countries = sanitizedAccount.Countries
-			existingAccount.LastReportedAt = sanitizedAccount.LastReportedAt
-			existingAccount.DataFormatVersion = sanitizedAccount.DataFormatVersion
+		changed, err := recordCountryEvents(tx, sanitizedAccount, nil)
+		if err != nil {
+			return false, err
+		}
+		countriesChanged = changed
+	} else if result.Error != nil {
+		return false, result.Error
+	} else {
+		// Update existing account
+		newReporter, err := recordReporter(tx, existingAccount.Platform, existingAccount.ID, clientHash, sanitizedAccount.LastReportedAt)
+		if err != nil {
+			return false, err
+		}
 
-			if err := tx.Save(&existingAccount).Error; err != nil {
-				return err
+		// Increment in place so concurrent reports are not lost
+		if newReporter {
+			err := tx.Model(&existingAccount).UpdateColumn("report_count", gorm.Expr("report_count + 1")).Error
+			if err != nil {
+				return false, err
 			}
 		}
-		return nil
-	})
 
-	if err != nil {
-		http.Error(w, "Database error", http.StatusInternalServerError)
-		return
+		changed, err := recordCountryEvents(tx, sanitizedAccount, existingAccount.Countries)
+		if err != nil {
+			return false, err
+		}
+		countriesChanged = changed
+
+		existingAccount.Name = sanitizedAccount.Name
+		existingAccount.Countries = sanitizedAccount.Countries
+		existingAccount.LastReportedAt = sanitizedAccount.LastReportedAt
+		existingAccount.DataFormatVersion = sanitizedAccount.DataFormatVersion
+		existingAccount.Reason = sanitizedAccount.Reason
+		existingAccount.Notice = sanitizedAccount.Notice
+
+		err = tx.Model(&existingAccount).
+			Select("Name", "Countries", "LastReportedAt", "DataFormatVersion", "Reason", "Notice").
+			Updates(&existingAccount).Error
+		if err != nil {
+			return false, err
+		}
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
+	if err := recordConfirmations(tx, sanitizedAccount.Platform, sanitizedAccount.ID, clientHash, sanitizedAccount.Countries, sanitizedAccount.LastReportedAt); err != nil {
+		return false, err
+	}
+
+	// Record the observation so the account's history is preserved
+	observation := models.Observation{
+		Platform:          sanitizedAccount.Platform,
+		AccountID:         sanitizedAccount.ID,
+		ClientHash:        clientHash,
+		Name:              sanitizedAccount.Name,
+		Countries:         sanitizedAccount.Countries,
+		ObservedAt:        sanitizedAccount.LastReportedAt,
+		DataFormatVersion: sanitizedAccount.DataFormatVersion,
+		Reason:            sanitizedAccount.Reason,
+		Notice:            sanitizedAccount.Notice,
+	}
+	return countriesChanged, tx.Create(&observation).Error
+}
+
+// recordReporter links a client to an account it reported and reports
+// whether the client had not reported the account before. The primary key
+// on account and client hash makes this a single indexed insert.
+func recordReporter(tx *gorm.DB, platform, accountID, clientHash string, at time.Time) (bool, error) {
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.AccountReporter{
+		Platform:        platform,
+		AccountID:       accountID,
+		ClientHash:      clientHash,
+		FirstReportedAt: at,
+		LastReportedAt:  at,
+	})
+	if result.Error != nil || result.RowsAffected > 0 {
+		return result.RowsAffected > 0, result.Error
+	}
+
+	// Refresh the link of a returning reporter so it is retained
+	err := tx.Model(&models.AccountReporter{}).
+		Where("platform = ? AND account_id = ? AND client_hash = ?", platform, accountID, clientHash).
+		Update("last_reported_at", at).Error
+	return false, err
 }
 
+// Account listing page sizes
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
 // GetAccountsHandler handles GET /api/accounts
 func (h *Handler) GetAccountsHandler(w http.ResponseWriter, r *http.Request) {
 	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
 	if page < 1 {
 		page = 1
 	}
-	country := r.URL.Query().Get("country")
-	search := r.URL.Query().Get("search")
-
-	pageSize := 20
-	offset := (page - 1) * pageSize
 
-	// Start building the query
-	query := h.db.Model(&models.Account{})
+	filters, err := parseAccountFilters(r.URL.Query())
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err)
+		return
+	}
 
-	// Apply filters
-	if country != "" {
-		query = query.Where("JSON_EXTRACT(countries, '$') LIKE ?", "%"+country+"%")
+	order, err := parseAccountSort(r.URL.Query(), filters, h.fullTextSearch)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err)
+		return
 	}
 
-	if search != "" {
-		query = query.Where("name LIKE ?", "%"+search+"%")
+	pageSize, err := validation.ParseLimit(r.URL.Query().Get("limit"), defaultPageSize, maxPageSize)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err)
+		return
 	}
+	offset := (page - 1) * pageSize
+
+	// Start building the query
+	query := filters.apply(h.db, h.db.Model(&models.Account{}), h.fullTextSearch)
 
 	// Get total count with filters
 	var totalCount int64
 	query.Count(&totalCount)
 
-	// Get filtered and paginated accounts
+	// A cursor takes precedence over page-based offsets, which skip or
+	// repeat rows when accounts are reported between page loads
+	if cursorParam := r.URL.Query().Get("cursor"); cursorParam != "" {
+		cursor, err := decodeCursor(cursorParam)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err)
+			return
+		}
+		query, err = order.after(query, cursor)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err)
+			return
+		}
+		offset = 0
+	}
+
+	// Get filtered and paginated accounts, fetching one extra row to
+	// determine whether a next page exists
 	var accounts []models.Account
-	result := query.Order("last_reported_at desc").
-		Limit(pageSize).
+	result := order.apply(query).
+		Limit(pageSize + 1).
 		Offset(offset).
 		Find(&accounts)
 
 	if result.Error != nil {
-		http.Error(w, "Database error", http.StatusInternalServerError)
+		writeDatabaseError(w, r)
 		return
 	}
 
-	// Get unique countries (from all accounts, not just filtered)
-	var uniqueCountries []string
-	uniqueCountriesMap := make(map[string]bool)
+	var nextCursor string
+	if len(accounts) > pageSize {
+		accounts = accounts[:pageSize]
+		if order.supportsCursor() {
+			nextCursor = encodeCursor(order, accounts[pageSize-1])
+		}
+	}
 
-	var allAccounts []models.Account
-	if err := h.db.Find(&allAccounts).Error; err != nil {
-		http.Error(w, "Database error", http.StatusInternalServerError)
+	if err := loadPreviousNames(h.db, accounts); err != nil {
+		writeDatabaseError(w, r)
 		return
 	}
 
-	for _, account := range allAccounts {
-		for _, country := range account.Countries {
-			uniqueCountriesMap[country] = true
-		}
+	if err := loadCountryConfidence(h.db, accounts); err != nil {
+		writeDatabaseError(w, r)
+		return
 	}
 
-	for country := range uniqueCountriesMap {
-		uniqueCountries = append(uniqueCountries, country)
+	reasons, err := filters.reasonCounts(h.db, h.fullTextSearch)
+	if err != nil {
+		writeDatabaseError(w, r)
+		return
+	}
+
+	// Get unique countries of all accounts on the listed platform, not just
+	// the filtered ones
+	uniqueCountries, err := h.uniqueCountries(filters.Platform)
+	if err != nil {
+		writeDatabaseError(w, r)
+		return
 	}
-	sort.Strings(uniqueCountries)
 
 	totalPages := int((totalCount + int64(pageSize) - 1) / int64(pageSize))
 
@@ -192,59 +395,34 @@ func (h *Handler) GetAccountsHandler(w http.ResponseWriter, r *http.Request) {
 		CurrentPage:     page,
 		TotalPages:      totalPages,
 		UniqueCountries: uniqueCountries,
+		Reasons:         reasons,
+		NextCursor:      nextCursor,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
-// DownloadCSVHandler handles GET /api/download
-func (h *Handler) DownloadCSVHandler(w http.ResponseWriter, r *http.Request) {
-	// Set headers for CSV download
-	w.Header().Set("Content-Type", "text/csv")
-	w.Header().Set("Content-Disposition", "attachment; filename=takedowns.csv")
-
-	// Get all accounts ordered by last reported time
-	var accounts []models.Account
-	result := h.db.Order("last_reported_at desc").Find(&accounts)
-	if result.Error != nil {
-		http.Error(w, "Database error", http.StatusInternalServerError)
+// DownloadHandler handles GET /api/download
+func (h *Handler) DownloadHandler(w http.ResponseWriter, r *http.Request) {
+	format, err := parseExportFormat(r.URL.Query())
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err)
 		return
 	}
 
-	// Create CSV writer
-	writer := csv.NewWriter(w)
-
-	// Write header
-	header := []string{"Account ID", "Username", "Countries", "Last Reported At", "Data Format Version"}
-	if err := writer.Write(header); err != nil {
-		http.Error(w, "Error writing CSV", http.StatusInternalServerError)
+	filters, err := parseAccountFilters(r.URL.Query())
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err)
 		return
 	}
 
-	// Write data
-	for _, account := range accounts {
-		// Join countries array with commas
-		countriesStr := strings.Join(account.Countries, ", ")
-
-		row := []string{
-			account.ID,
-			account.Name,
-			countriesStr,
-			account.LastReportedAt.Format(time.RFC3339),
-			account.DataFormatVersion,
-		}
-		if err := writer.Write(row); err != nil {
-			http.Error(w, "Error writing CSV", http.StatusInternalServerError)
-			return
-		}
-	}
-
-	// Flush the writer
-	writer.Flush()
-
-	if err := writer.Error(); err != nil {
-		http.Error(w, "Error writing CSV", http.StatusInternalServerError)
+	order, err := parseAccountSort(r.URL.Query(), filters, h.fullTextSearch)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err)
 		return
 	}
+
+	query := filters.apply(h.db, h.db.Model(&models.Account{}), h.fullTextSearch)
+	streamDownload(w, r, h.db, order.apply(query), accountExportSchema, format)
 }
@@ -8,10 +8,12 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"sort"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/takedown-observer/backend/db"
 	"github.com/takedown-observer/backend/models"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -19,17 +21,17 @@ import (
 
 func newTestDB(t *testing.T) *gorm.DB {
 	dbName := fmt.Sprintf("file::memory:?db=%p", t.Name)
-	db, err := gorm.Open(sqlite.Open(dbName), &gorm.Config{})
+	testDB, err := gorm.Open(sqlite.Open(dbName), &gorm.Config{})
 	if err != nil {
 		t.Fatalf("Failed to open test database: %v", err)
 	}
 
-	err = db.AutoMigrate(&models.Account{})
+	err = db.Migrate(testDB, nil)
 	if err != nil {
 		t.Fatalf("Failed to migrate test database: %v", err)
 	}
 
-	return db
+	return testDB
 }
 
 func TestReportHandler(t *testing.T) {
@@ -66,6 +68,15 @@ func TestReportHandler(t *testing.T) {
 				if account.ReportCount != 1 {
 					t.Errorf("Expected report count 1, got %d", account.ReportCount)
 				}
+
+				var observations []models.Observation
+				db.Where("account_id = ?", "test_account1").Find(&observations)
+				if len(observations) != 1 {
+					t.Fatalf("Expected 1 observation, got %d", len(observations))
+				}
+				if observations[0].ClientHash == "" || observations[0].ClientHash == "123e4567-e89b-12d3-a456-426614174000" {
+					t.Errorf("Expected hashed client ID, got %q", observations[0].ClientHash)
+				}
 			},
 		},
 		{
@@ -86,7 +97,6 @@ func TestReportHandler(t *testing.T) {
 					Countries:         []string{"US"},
 					LastReportedAt:    time.Now(),
 					ReportCount:       1,
-					ReportedBy:        []string{"other-client"},
 					DataFormatVersion: "1.0",
 				}
 				return db.Create(&account).Error
@@ -106,6 +116,51 @@ func TestReportHandler(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "valid report - repeated by the same client",
+			request: models.ReportRequest{
+				ClientID: "123e4567-e89b-12d3-a456-426614174000",
+				Account: models.ReportedAccount{
+					ID:        "test_account3",
+					Name:      "TestAccount",
+					Countries: []string{"US"},
+				},
+				DataFormatVersion: "1.0",
+			},
+			setupDB: func(t *testing.T, db *gorm.DB) error {
+				account := models.Account{
+					ID:                "test_account3",
+					Name:              "TestAccount",
+					Countries:         []string{"US"},
+					LastReportedAt:    time.Now().Add(-time.Hour),
+					ReportCount:       1,
+					DataFormatVersion: "1.0",
+				}
+				if err := db.Create(&account).Error; err != nil {
+					return err
+				}
+				return db.Create(&models.AccountReporter{
+					AccountID:       "test_account3",
+					ClientHash:      NewHandler(db).hashClientID("123e4567-e89b-12d3-a456-426614174000"),
+					FirstReportedAt: account.LastReportedAt,
+					LastReportedAt:  account.LastReportedAt,
+				}).Error
+			},
+			expectedCode: http.StatusOK,
+			checkDB: func(t *testing.T, db *gorm.DB) {
+				var account models.Account
+				db.First(&account, "id = ?", "test_account3")
+				if account.ReportCount != 1 {
+					t.Errorf("Expected report count 1, got %d", account.ReportCount)
+				}
+
+				var reporters []models.AccountReporter
+				db.Where("account_id = ?", "test_account3").Find(&reporters)
+				if len(reporters) != 1 || !reporters[0].LastReportedAt.Equal(account.LastReportedAt) {
+					t.Errorf("Expected the reporter link to be refreshed, got %v", reporters)
+				}
+			},
+		},
 		{
 			name: "invalid UUID",
 			request: models.ReportRequest{
@@ -121,6 +176,94 @@ func TestReportHandler(t *testing.T) {
 			expectedCode:  http.StatusBadRequest,
 			expectedError: "Invalid client ID format",
 		},
+		{
+			name: "valid report - reason and notice",
+			request: models.ReportRequest{
+				ClientID: "123e4567-e89b-12d3-a456-426614174000",
+				Account: models.ReportedAccount{
+					ID:        "test_account4",
+					Name:      "TestAccount",
+					Countries: []string{"IN"},
+					Reason:    "legal_demand",
+					Notice:    "Account withheld in India in response to a legal demand.",
+				},
+				DataFormatVersion: "1.0",
+			},
+			setupDB:      func(t *testing.T, db *gorm.DB) error { return nil },
+			expectedCode: http.StatusOK,
+			checkDB: func(t *testing.T, db *gorm.DB) {
+				var account models.Account
+				db.First(&account, "id = ?", "test_account4")
+				if account.Reason != "legal_demand" {
+					t.Errorf("Expected reason legal_demand, got %q", account.Reason)
+				}
+
+				var observation models.Observation
+				db.First(&observation, "account_id = ?", "test_account4")
+				if observation.Reason != "legal_demand" || observation.Notice != "Account withheld in India in response to a legal demand." {
+					t.Errorf("Expected the reason and notice to be observed, got %q and %q", observation.Reason, observation.Notice)
+				}
+
+				var event models.CountryEvent
+				db.First(&event, "account_id = ?", "test_account4")
+				if event.Reason != "legal_demand" {
+					t.Errorf("Expected imposed event with reason legal_demand, got %q", event.Reason)
+				}
+			},
+		},
+		{
+			name: "valid report - no reason",
+			request: models.ReportRequest{
+				ClientID: "123e4567-e89b-12d3-a456-426614174000",
+				Account: models.ReportedAccount{
+					ID:        "test_account5",
+					Name:      "TestAccount",
+					Countries: []string{"IN"},
+				},
+				DataFormatVersion: "1.0",
+			},
+			setupDB:      func(t *testing.T, db *gorm.DB) error { return nil },
+			expectedCode: http.StatusOK,
+			checkDB: func(t *testing.T, db *gorm.DB) {
+				var account models.Account
+				db.First(&account, "id = ?", "test_account5")
+				if account.Reason != "unspecified" {
+					t.Errorf("Expected reason unspecified, got %q", account.Reason)
+				}
+			},
+		},
+		{
+			name: "invalid reason",
+			request: models.ReportRequest{
+				ClientID: "123e4567-e89b-12d3-a456-426614174000",
+				Account: models.ReportedAccount{
+					ID:        "test_account",
+					Name:      "TestAccount",
+					Countries: []string{"US"},
+					Reason:    "copyright",
+				},
+				DataFormatVersion: "1.0",
+			},
+			setupDB:       func(t *testing.T, db *gorm.DB) error { return nil },
+			expectedCode:  http.StatusBadRequest,
+			expectedError: "reason must be one of",
+		},
+		{
+			name: "notice too long",
+			request: models.ReportRequest{
+				ClientID: "123e4567-e89b-12d3-a456-426614174000",
+				Account: models.ReportedAccount{
+					ID:        "test_account",
+					Name:      "TestAccount",
+					Countries: []string{"US"},
+					Notice:    strings.Repeat("a", 1001),
+				},
+				DataFormatVersion: "1.0",
+			},
+			setupDB:       func(t *testing.T, db *gorm.DB) error { return nil },
+			expectedCode:  http.StatusBadRequest,
+			expectedError: "notice exceeds maximum length",
+		},
 	}
 
 	for _, tt := range tests {
@@ -177,7 +320,6 @@ func TestGetAccountsHandler(t *testing.T) {
 					Countries:         []string{"IN", "BR"},
 					LastReportedAt:    time.Now(),
 					ReportCount:       1,
-					ReportedBy:        []string{"client1"},
 					DataFormatVersion: "1.0",
 				}
 				return db.Create(&account).Error
@@ -200,6 +342,33 @@ func TestGetAccountsHandler(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "successful retrieval - with platform filter",
+			queryParams: map[string]string{
+				"platform": "telegram",
+			},
+			setupDB: func(t *testing.T, db *gorm.DB) error {
+				accounts := []models.Account{
+					{ID: "account1", Name: "Account1", Countries: []string{"IN"}, LastReportedAt: time.Now()},
+					{Platform: "telegram", ID: "channel1", Name: "Channel1", Countries: []string{"RU"}, LastReportedAt: time.Now()},
+				}
+				return db.Create(&accounts).Error
+			},
+			expectedCode: http.StatusOK,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var response models.AccountsResponse
+				if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+					t.Errorf("Failed to decode response: %v", err)
+					return
+				}
+				if len(response.Accounts) != 1 || response.Accounts[0].Platform != "telegram" {
+					t.Errorf("Expected the telegram account, got %v", response.Accounts)
+				}
+				if !reflect.DeepEqual(response.UniqueCountries, []string{"RU"}) {
+					t.Errorf("Expected unique countries of the platform [RU], got %v", response.UniqueCountries)
+				}
+			},
+		},
 		{
 			name: "successful retrieval - with country filter",
 			queryParams: map[string]string{
@@ -214,7 +383,6 @@ func TestGetAccountsHandler(t *testing.T) {
 						Countries:         []string{"US"},
 						LastReportedAt:    time.Now(),
 						ReportCount:       1,
-						ReportedBy:        []string{"client1"},
 						DataFormatVersion: "1.0",
 					},
 					{
@@ -223,7 +391,6 @@ func TestGetAccountsHandler(t *testing.T) {
 						Countries:         []string{"GB"},
 						LastReportedAt:    time.Now(),
 						ReportCount:       1,
-						ReportedBy:        []string{"client1"},
 						DataFormatVersion: "1.0",
 					},
 				}
@@ -282,9 +449,417 @@ func TestGetAccountsHandler(t *testing.T) {
 	}
 }
 
+func TestGetAccountsHandlerCountryFilters(t *testing.T) {
+	db := newTestDB(t)
+	handler := NewHandler(db)
+	clientID := "123e4567-e89b-12d3-a456-426614174000"
+
+	postReport(t, handler, clientID, "both", "Both", []string{"DE", "FR"})
+	postReport(t, handler, clientID, "germany", "Germany", []string{"DE"})
+	postReport(t, handler, clientID, "india", "India", []string{"IN", "US"})
+	postReport(t, handler, clientID, "lifted", "Lifted", []string{"FR", "US"})
+	postReport(t, handler, clientID, "lifted", "Lifted", []string{"US"})
+
+	tests := []struct {
+		name         string
+		params       string
+		expectedCode int
+		expectedIDs  []string
+	}{
+		{
+			name:         "any of several countries",
+			params:       "country=FR,IN",
+			expectedCode: http.StatusOK,
+			expectedIDs:  []string{"both", "india"},
+		},
+		{
+			name:         "all of several countries",
+			params:       "country=DE,FR&match=all",
+			expectedCode: http.StatusOK,
+			expectedIDs:  []string{"both"},
+		},
+		{
+			name:         "excluded country",
+			params:       "exclude_country=US",
+			expectedCode: http.StatusOK,
+			expectedIDs:  []string{"both", "germany"},
+		},
+		{
+			name:         "included and excluded countries",
+			params:       "country=DE&exclude_country=FR",
+			expectedCode: http.StatusOK,
+			expectedIDs:  []string{"germany"},
+		},
+		{
+			name:         "country codes are matched exactly",
+			params:       "country=U",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid match mode",
+			params:       "country=DE&match=most",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/accounts?"+tt.params, nil)
+			w := httptest.NewRecorder()
+			handler.GetAccountsHandler(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("GetAccountsHandler() status code = %v, want %v", w.Code, tt.expectedCode)
+			}
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+
+			var response models.AccountsResponse
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+
+			var ids []string
+			for _, account := range response.Accounts {
+				ids = append(ids, account.ID)
+			}
+			sort.Strings(ids)
+			if !reflect.DeepEqual(ids, tt.expectedIDs) {
+				t.Errorf("Expected accounts %v, got %v", tt.expectedIDs, ids)
+			}
+		})
+	}
+}
+
+func TestGetAccountsHandlerReasonFilter(t *testing.T) {
+	db := newTestDB(t)
+
+	accounts := []models.Account{
+		{ID: "a", Name: "Alpha", Countries: []string{"DE"}, Reason: "legal_demand"},
+		{ID: "b", Name: "Bravo", Countries: []string{"DE"}, Reason: "legal_demand"},
+		{ID: "c", Name: "Charlie", Countries: []string{"FR"}, Reason: "local_laws"},
+		{ID: "d", Name: "Delta", Countries: []string{"DE"}},
+	}
+	for _, account := range accounts {
+		if err := db.Create(&account).Error; err != nil {
+			t.Fatalf("Failed to setup test database: %v", err)
+		}
+	}
+
+	handler := NewHandler(db)
+
+	tests := []struct {
+		params          string
+		expectedCode    int
+		expectedIDs     []string
+		expectedReasons []models.ReasonCount
+	}{
+		{
+			params:       "",
+			expectedCode: http.StatusOK,
+			expectedIDs:  []string{"a", "b", "c", "d"},
+			expectedReasons: []models.ReasonCount{
+				{Reason: "legal_demand", Count: 2},
+				{Reason: "local_laws", Count: 1},
+				{Reason: "unspecified", Count: 1},
+			},
+		},
+		{
+			params:       "reason=legal_demand",
+			expectedCode: http.StatusOK,
+			expectedIDs:  []string{"a", "b"},
+			expectedReasons: []models.ReasonCount{
+				{Reason: "legal_demand", Count: 2},
+				{Reason: "local_laws", Count: 1},
+				{Reason: "unspecified", Count: 1},
+			},
+		},
+		{
+			params:       "reason=local_laws,unspecified&country=DE",
+			expectedCode: http.StatusOK,
+			expectedIDs:  []string{"d"},
+			expectedReasons: []models.ReasonCount{
+				{Reason: "legal_demand", Count: 2},
+				{Reason: "unspecified", Count: 1},
+			},
+		},
+		{
+			params:       "reason=copyright",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.params, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/accounts?"+tt.params, nil)
+			w := httptest.NewRecorder()
+			handler.GetAccountsHandler(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("GetAccountsHandler() status code = %v, want %v", w.Code, tt.expectedCode)
+			}
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+
+			var response models.AccountsResponse
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+
+			var ids []string
+			for _, account := range response.Accounts {
+				ids = append(ids, account.ID)
+			}
+			sort.Strings(ids)
+			if !reflect.DeepEqual(ids, tt.expectedIDs) {
+				t.Errorf("Expected accounts %v, got %v", tt.expectedIDs, ids)
+			}
+			if !reflect.DeepEqual(response.Reasons, tt.expectedReasons) {
+				t.Errorf("Expected reasons %v, got %v", tt.expectedReasons, response.Reasons)
+			}
+		})
+	}
+}
+
+func TestGetAccountsHandlerSortAndRangeFilters(t *testing.T) {
+	db := newTestDB(t)
+	base := time.Date(2025, 2, 20, 12, 0, 0, 0, time.UTC)
+
+	accounts := []models.Account{
+		{ID: "a", Name: "Charlie", Countries: []string{"DE"}, FirstSeenAt: base, LastReportedAt: base.Add(72 * time.Hour), ReportCount: 5},
+		{ID: "b", Name: "Alpha", Countries: []string{"DE", "FR"}, FirstSeenAt: base.Add(24 * time.Hour), LastReportedAt: base.Add(24 * time.Hour), ReportCount: 1},
+		{ID: "c", Name: "Bravo", Countries: []string{"DE", "FR", "IN"}, FirstSeenAt: base.Add(48 * time.Hour), LastReportedAt: base.Add(48 * time.Hour), ReportCount: 3},
+	}
+	for _, account := range accounts {
+		if err := db.Create(&account).Error; err != nil {
+			t.Fatalf("Failed to setup test database: %v", err)
+		}
+	}
+
+	handler := NewHandler(db)
+
+	tests := []struct {
+		name          string
+		params        string
+		expectedCode  int
+		expectedIDs   []string
+		expectedError string
+	}{
+		{
+			name:         "default order",
+			params:       "",
+			expectedCode: http.StatusOK,
+			expectedIDs:  []string{"a", "c", "b"},
+		},
+		{
+			name:         "sort by report count",
+			params:       "sort=report_count",
+			expectedCode: http.StatusOK,
+			expectedIDs:  []string{"a", "c", "b"},
+		},
+		{
+			name:         "sort by name ascending",
+			params:       "sort=name&order=asc",
+			expectedCode: http.StatusOK,
+			expectedIDs:  []string{"b", "c", "a"},
+		},
+		{
+			name:         "sort by first seen ascending",
+			params:       "sort=first_seen_at&order=asc",
+			expectedCode: http.StatusOK,
+			expectedIDs:  []string{"a", "b", "c"},
+		},
+		{
+			name:         "reported range",
+			params:       "reported_after=2025-02-21T00:00:00Z&reported_before=2025-02-23T00:00:00Z",
+			expectedCode: http.StatusOK,
+			expectedIDs:  []string{"c", "b"},
+		},
+		{
+			name:         "minimum reports",
+			params:       "min_reports=3",
+			expectedCode: http.StatusOK,
+			expectedIDs:  []string{"a", "c"},
+		},
+		{
+			name:         "minimum countries",
+			params:       "min_countries=2",
+			expectedCode: http.StatusOK,
+			expectedIDs:  []string{"c", "b"},
+		},
+		{
+			name:          "unknown sort column",
+			params:        "sort=reported_by",
+			expectedCode:  http.StatusBadRequest,
+			expectedError: "sort must be one of",
+		},
+		{
+			name:          "invalid order",
+			params:        "order=up",
+			expectedCode:  http.StatusBadRequest,
+			expectedError: "order must be one of",
+		},
+		{
+			name:          "invalid timestamp",
+			params:        "reported_after=yesterday",
+			expectedCode:  http.StatusBadRequest,
+			expectedError: "reported_after",
+		},
+		{
+			name:          "reversed range",
+			params:        "reported_after=2025-02-23T00:00:00Z&reported_before=2025-02-21T00:00:00Z",
+			expectedCode:  http.StatusBadRequest,
+			expectedError: "is not before",
+		},
+		{
+			name:          "negative minimum reports",
+			params:        "min_reports=-1",
+			expectedCode:  http.StatusBadRequest,
+			expectedError: "min_reports",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/accounts?"+tt.params, nil)
+			w := httptest.NewRecorder()
+			handler.GetAccountsHandler(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("GetAccountsHandler() status code = %v, want %v", w.Code, tt.expectedCode)
+			}
+
+			if tt.expectedError != "" && !strings.Contains(w.Body.String(), tt.expectedError) {
+				t.Errorf("GetAccountsHandler() error = %v, want %v", w.Body.String(), tt.expectedError)
+			}
+
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+
+			var response models.AccountsResponse
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+
+			var ids []string
+			for _, account := range response.Accounts {
+				ids = append(ids, account.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.expectedIDs) {
+				t.Errorf("Expected accounts %v, got %v", tt.expectedIDs, ids)
+			}
+		})
+	}
+
+	// Cursors follow the requested sort order
+	req := httptest.NewRequest("GET", "/api/accounts?sort=name&order=asc&limit=2", nil)
+	w := httptest.NewRecorder()
+	handler.GetAccountsHandler(w, req)
+	var response models.AccountsResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	req = httptest.NewRequest("GET", "/api/accounts?sort=name&order=asc&limit=2&cursor="+response.NextCursor, nil)
+	w = httptest.NewRecorder()
+	handler.GetAccountsHandler(w, req)
+	response = models.AccountsResponse{}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Accounts) != 1 || response.Accounts[0].ID != "a" {
+		t.Errorf("Expected second page to contain account a, got %v", response.Accounts)
+	}
+
+	if response.NextCursor != "" {
+		t.Errorf("Expected no cursor after the last page, got %q", response.NextCursor)
+	}
+
+	req = httptest.NewRequest("GET", "/api/accounts?limit=1", nil)
+	w = httptest.NewRecorder()
+	handler.GetAccountsHandler(w, req)
+	response = models.AccountsResponse{}
+	json.NewDecoder(w.Body).Decode(&response)
+
+	req = httptest.NewRequest("GET", "/api/accounts?sort=name&cursor="+response.NextCursor, nil)
+	w = httptest.NewRecorder()
+	handler.GetAccountsHandler(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for cursor from a different sort, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestGetAccountsHandlerCursorPagination(t *testing.T) {
+	db := newTestDB(t)
+	base := time.Date(2025, 2, 20, 12, 0, 0, 0, time.UTC)
+
+	// Two accounts share a timestamp to exercise the id tiebreaker
+	for i, offset := range []time.Duration{0, time.Hour, time.Hour, 2 * time.Hour, 3 * time.Hour} {
+		account := models.Account{
+			ID:                fmt.Sprintf("account%d", i),
+			Name:              fmt.Sprintf("Account%d", i),
+			Countries:         []string{"US"},
+			LastReportedAt:    base.Add(offset),
+			ReportCount:       1,
+			DataFormatVersion: "1.0",
+		}
+		if err := db.Create(&account).Error; err != nil {
+			t.Fatalf("Failed to setup test database: %v", err)
+		}
+	}
+
+	handler := NewHandler(db)
+
+	fetch := func(params string) models.AccountsResponse {
+		req := httptest.NewRequest("GET", "/api/accounts?"+params, nil)
+		w := httptest.NewRecorder()
+		handler.GetAccountsHandler(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GetAccountsHandler() status code = %v, body = %s", w.Code, w.Body.String())
+		}
+		var response models.AccountsResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return response
+	}
+
+	var ids []string
+	response := fetch("limit=2")
+	for {
+		for _, account := range response.Accounts {
+			ids = append(ids, account.ID)
+		}
+		if response.NextCursor == "" {
+			break
+		}
+
+		// A report arriving between page loads must not shift later pages
+		db.Create(&models.Account{ID: fmt.Sprintf("new%d", len(ids)), Name: "New", LastReportedAt: base.Add(24 * time.Hour)})
+
+		response = fetch("limit=2&cursor=" + response.NextCursor)
+	}
+
+	expected := []string{"account4", "account3", "account2", "account1", "account0"}
+	if !reflect.DeepEqual(ids, expected) {
+		t.Errorf("Expected accounts %v, got %v", expected, ids)
+	}
+
+	req := httptest.NewRequest("GET", "/api/accounts?cursor=not-a-cursor", nil)
+	w := httptest.NewRecorder()
+	handler.GetAccountsHandler(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for invalid cursor, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
 func TestDownloadCSVHandler(t *testing.T) {
 	tests := []struct {
 		name          string
+		queryParams   map[string]string
 		setupDB       func(*testing.T, *gorm.DB) error
 		expectedCode  int
 		checkResponse func(*testing.T, *httptest.ResponseRecorder)
@@ -299,7 +874,6 @@ func TestDownloadCSVHandler(t *testing.T) {
 						Countries:         []string{"US", "GB"},
 						LastReportedAt:    time.Date(2025, 2, 20, 14, 0, 0, 0, time.UTC),
 						ReportCount:       1,
-						ReportedBy:        []string{"client1"},
 						DataFormatVersion: "1.0",
 					},
 					{
@@ -308,7 +882,6 @@ func TestDownloadCSVHandler(t *testing.T) {
 						Countries:         []string{"FR", "DE"},
 						LastReportedAt:    time.Date(2025, 2, 20, 13, 0, 0, 0, time.UTC),
 						ReportCount:       2,
-						ReportedBy:        []string{"client1", "client2"},
 						DataFormatVersion: "1.0",
 					},
 				}
@@ -343,7 +916,7 @@ func TestDownloadCSVHandler(t *testing.T) {
 				}
 
 				// Check header row
-				expectedHeader := []string{"Account ID", "Username", "Countries", "Last Reported At", "Data Format Version"}
+				expectedHeader := []string{"Account ID", "Username", "Countries", "Last Reported At", "Data Format Version", "Platform"}
 				if !reflect.DeepEqual(records[0], expectedHeader) {
 					t.Errorf("Expected CSV header %v, got %v", expectedHeader, records[0])
 				}
@@ -399,7 +972,7 @@ func TestDownloadCSVHandler(t *testing.T) {
 					t.Errorf("Expected 1 CSV record (header only), got %d", len(records))
 				}
 
-				expectedHeader := "Account ID,Username,Countries,Last Reported At,Data Format Version"
+				expectedHeader := "Account ID,Username,Countries,Last Reported At,Data Format Version,Platform"
 				if records[0] != expectedHeader {
 					t.Errorf("Expected CSV header %s, got %s", expectedHeader, records[0])
 				}
@@ -414,7 +987,6 @@ func TestDownloadCSVHandler(t *testing.T) {
 					Countries:         []string{"US", "GB"},
 					LastReportedAt:    time.Date(2025, 2, 20, 12, 0, 0, 0, time.UTC),
 					ReportCount:       1,
-					ReportedBy:        []string{"client1"},
 					DataFormatVersion: "1.0",
 				}
 				return db.Create(&account).Error
@@ -437,6 +1009,66 @@ func TestDownloadCSVHandler(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "filtered download",
+			queryParams: map[string]string{
+				"country":        "DE",
+				"reported_after": "2025-02-20T00:00:00Z",
+			},
+			setupDB: func(t *testing.T, db *gorm.DB) error {
+				accounts := []models.Account{
+					{ID: "match", Name: "Match", Countries: []string{"DE"}, LastReportedAt: time.Date(2025, 2, 20, 12, 0, 0, 0, time.UTC)},
+					{ID: "old", Name: "Old", Countries: []string{"DE"}, LastReportedAt: time.Date(2025, 2, 19, 12, 0, 0, 0, time.UTC)},
+					{ID: "other", Name: "Other", Countries: []string{"FR"}, LastReportedAt: time.Date(2025, 2, 20, 12, 0, 0, 0, time.UTC)},
+				}
+				return db.Create(&accounts).Error
+			},
+			expectedCode: http.StatusOK,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				records, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+				if err != nil {
+					t.Fatalf("Failed to parse CSV: %v", err)
+				}
+				if len(records) != 2 || records[1][0] != "match" {
+					t.Errorf("Expected only the matching account, got %v", records)
+				}
+			},
+		},
+		{
+			name: "large download spans several flushes",
+			setupDB: func(t *testing.T, db *gorm.DB) error {
+				accounts := make([]models.Account, 2*exportFlushInterval+1)
+				for i := range accounts {
+					accounts[i] = models.Account{
+						ID:             fmt.Sprintf("account%d", i),
+						Name:           fmt.Sprintf("Account%d", i),
+						Countries:      []string{"US"},
+						LastReportedAt: time.Date(2025, 2, 20, 12, 0, 0, 0, time.UTC),
+					}
+				}
+				return db.CreateInBatches(&accounts, 100).Error
+			},
+			expectedCode: http.StatusOK,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				records, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+				if err != nil {
+					t.Fatalf("Failed to parse CSV: %v", err)
+				}
+				if len(records) != 2*exportFlushInterval+2 {
+					t.Errorf("Expected %d CSV records, got %d", 2*exportFlushInterval+2, len(records))
+				}
+			},
+		},
+		{
+			name: "invalid filter",
+			queryParams: map[string]string{
+				"country": "Germany",
+			},
+			setupDB: func(t *testing.T, db *gorm.DB) error {
+				return nil
+			},
+			expectedCode: http.StatusBadRequest,
+		},
 	}
 
 	for _, tt := range tests {
@@ -450,12 +1082,17 @@ func TestDownloadCSVHandler(t *testing.T) {
 			handler := NewHandler(db)
 
 			req := httptest.NewRequest("GET", "/api/download", nil)
+			q := req.URL.Query()
+			for key, value := range tt.queryParams {
+				q.Add(key, value)
+			}
+			req.URL.RawQuery = q.Encode()
 			w := httptest.NewRecorder()
 
-			handler.DownloadCSVHandler(w, req)
+			handler.DownloadHandler(w, req)
 
 			if w.Code != tt.expectedCode {
-				t.Errorf("DownloadCSVHandler() status code = %v, want %v", w.Code, tt.expectedCode)
+				t.Errorf("DownloadHandler() status code = %v, want %v", w.Code, tt.expectedCode)
 			}
 
 			if tt.checkResponse != nil {
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+
+	"github.com/gorilla/mux"
+	"github.com/takedown-observer/backend/models"
+	"gorm.io/gorm"
+)
+
+// GetAccountHistoryHandler handles GET /api/accounts/{id}/history
+func (h *Handler) GetAccountHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	accountID := mux.Vars(r)["id"]
+
+	platform, err := parsePlatform(r.URL.Query())
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err)
+		return
+	}
+
+	var account models.Account
+	if err := h.db.First(&account, "platform = ? AND id = ?", platform, accountID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			WriteProblem(w, r, http.StatusNotFound, codeNotFound, "", "Account not found")
+			return
+		}
+		writeDatabaseError(w, r)
+		return
+	}
+
+	var observations []models.Observation
+	result := h.db.Where("platform = ? AND account_id = ?", platform, accountID).
+		Order("observed_at asc, id asc").
+		Find(&observations)
+	if result.Error != nil {
+		writeDatabaseError(w, r)
+		return
+	}
+
+	response := models.HistoryResponse{
+		Platform:  account.Platform,
+		AccountID: account.ID,
+		History:   buildHistory(observations),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// buildHistory collapses consecutive observations with the same set of
+// countries into a single history entry. Observations must be ordered by
+// observation time.
+func buildHistory(observations []models.Observation) []models.HistoryEntry {
+	history := []models.HistoryEntry{}
+
+	for _, observation := range observations {
+		countries := slices.Clone(observation.Countries)
+		slices.Sort(countries)
+
+		if n := len(history); n > 0 && slices.Equal(history[n-1].Countries, countries) {
+			history[n-1].LastObservedAt = observation.ObservedAt
+			history[n-1].ObservationCount++
+			continue
+		}
+
+		history = append(history, models.HistoryEntry{
+			Countries:        countries,
+			FirstObservedAt:  observation.ObservedAt,
+			LastObservedAt:   observation.ObservedAt,
+			ObservationCount: 1,
+		})
+	}
+
+	return history
+}
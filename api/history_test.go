@@ -0,0 +1,118 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/takedown-observer/backend/models"
+	"gorm.io/gorm"
+)
+
+func TestGetAccountHistoryHandler(t *testing.T) {
+	base := time.Date(2025, 2, 20, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		accountID     string
+		setupDB       func(*testing.T, *gorm.DB) error
+		expectedCode  int
+		checkResponse func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "collapses consecutive identical country sets",
+			accountID: "account1",
+			setupDB: func(t *testing.T, db *gorm.DB) error {
+				account := models.Account{
+					ID:                "account1",
+					Name:              "Account1",
+					Countries:         []string{"DE", "FR"},
+					LastReportedAt:    base.Add(3 * time.Hour),
+					ReportCount:       2,
+					DataFormatVersion: "1.0",
+				}
+				if err := db.Create(&account).Error; err != nil {
+					return err
+				}
+				observations := []models.Observation{
+					{AccountID: "account1", Countries: []string{"DE"}, ObservedAt: base},
+					{AccountID: "account1", Countries: []string{"DE"}, ObservedAt: base.Add(time.Hour)},
+					{AccountID: "account1", Countries: []string{"FR", "DE"}, ObservedAt: base.Add(2 * time.Hour)},
+					{AccountID: "account1", Countries: []string{"DE", "FR"}, ObservedAt: base.Add(3 * time.Hour)},
+					{AccountID: "other", Countries: []string{"US"}, ObservedAt: base},
+				}
+				return db.Create(&observations).Error
+			},
+			expectedCode: http.StatusOK,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var response models.HistoryResponse
+				if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+					t.Fatalf("Failed to decode response: %v", err)
+				}
+				if response.AccountID != "account1" {
+					t.Errorf("Expected account ID account1, got %s", response.AccountID)
+				}
+				if len(response.History) != 2 {
+					t.Fatalf("Expected 2 history entries, got %d", len(response.History))
+				}
+
+				first := response.History[0]
+				if !reflect.DeepEqual(first.Countries, []string{"DE"}) {
+					t.Errorf("Expected first entry countries [DE], got %v", first.Countries)
+				}
+				if first.ObservationCount != 2 {
+					t.Errorf("Expected first entry observation count 2, got %d", first.ObservationCount)
+				}
+				if !first.FirstObservedAt.Equal(base) || !first.LastObservedAt.Equal(base.Add(time.Hour)) {
+					t.Errorf("Unexpected first entry range %v - %v", first.FirstObservedAt, first.LastObservedAt)
+				}
+
+				second := response.History[1]
+				if !reflect.DeepEqual(second.Countries, []string{"DE", "FR"}) {
+					t.Errorf("Expected second entry countries [DE FR], got %v", second.Countries)
+				}
+				if second.ObservationCount != 2 {
+					t.Errorf("Expected second entry observation count 2, got %d", second.ObservationCount)
+				}
+			},
+		},
+		{
+			name:      "unknown account",
+			accountID: "missing",
+			setupDB: func(t *testing.T, db *gorm.DB) error {
+				return nil
+			},
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+
+			if err := tt.setupDB(t, db); err != nil {
+				t.Fatalf("Failed to setup test database: %v", err)
+			}
+
+			handler := NewHandler(db)
+
+			req := httptest.NewRequest("GET", "/api/accounts/"+tt.accountID+"/history", nil)
+			req = mux.SetURLVars(req, map[string]string{"id": tt.accountID})
+			w := httptest.NewRecorder()
+
+			handler.GetAccountHistoryHandler(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("GetAccountHistoryHandler() status code = %v, want %v", w.Code, tt.expectedCode)
+			}
+
+			if tt.checkResponse != nil {
+				tt.checkResponse(t, w)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"github.com/takedown-observer/backend/models"
+	"gorm.io/gorm"
+)
+
+// loadPreviousNames fills in the names each account was observed under
+// before its current name, most recent first
+func loadPreviousNames(db *gorm.DB, accounts []models.Account) error {
+	if len(accounts) == 0 {
+		return nil
+	}
+
+	var names []models.AccountName
+	err := db.Where("(platform, account_id) IN ?", accountKeyValues(accounts)).
+		Order("last_seen_at desc, name asc").
+		Find(&names).Error
+	if err != nil {
+		return err
+	}
+
+	byAccount := make(map[accountKey][]string)
+	for _, name := range names {
+		key := accountKey{Platform: name.Platform, ID: name.AccountID}
+		byAccount[key] = append(byAccount[key], name.Name)
+	}
+
+	for i := range accounts {
+		for _, name := range byAccount[keyOf(accounts[i])] {
+			if name != accounts[i].Name {
+				accounts[i].PreviousNames = append(accounts[i].PreviousNames, name)
+			}
+		}
+	}
+
+	return nil
+}
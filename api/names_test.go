@@ -0,0 +1,58 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/takedown-observer/backend/models"
+)
+
+func TestReportHandlerRecordsNameHistory(t *testing.T) {
+	db := newTestDB(t)
+	handler := NewHandler(db)
+	clientID := "123e4567-e89b-12d3-a456-426614174000"
+
+	postReport(t, handler, clientID, "account1", "first_name", []string{"DE"})
+	postReport(t, handler, clientID, "account1", "second_name", []string{"DE"})
+	postReport(t, handler, clientID, "account1", "first_name", []string{"DE"})
+	postReport(t, handler, clientID, "account1", "third_name", []string{"DE"})
+
+	var names []models.AccountName
+	db.Where("account_id = ?", "account1").Order("first_seen_at asc").Find(&names)
+	if len(names) != 3 {
+		t.Fatalf("Expected 3 distinct names, got %v", names)
+	}
+
+	first := names[0]
+	if first.Name != "first_name" || !first.LastSeenAt.After(first.FirstSeenAt) {
+		t.Errorf("Expected first_name to span two reports, got %+v", first)
+	}
+
+	req := httptest.NewRequest("GET", "/api/accounts", nil)
+	w := httptest.NewRecorder()
+	handler.GetAccountsHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("GetAccountsHandler() status code = %v, want %v", w.Code, http.StatusOK)
+	}
+
+	var response models.AccountsResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Accounts) != 1 {
+		t.Fatalf("Expected 1 account, got %d", len(response.Accounts))
+	}
+
+	account := response.Accounts[0]
+	if account.Name != "third_name" {
+		t.Errorf("Expected current name third_name, got %s", account.Name)
+	}
+	expected := []string{"first_name", "second_name"}
+	if !reflect.DeepEqual(account.PreviousNames, expected) {
+		t.Errorf("Expected previous names %v, got %v", expected, account.PreviousNames)
+	}
+}
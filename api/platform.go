@@ -0,0 +1,40 @@
+package api
+
+import (
+	"net/url"
+
+	"github.com/takedown-observer/backend/models"
+	"github.com/takedown-observer/backend/validation"
+)
+
+// accountKey identifies an account. Account IDs are only unique within a
+// platform.
+type accountKey struct {
+	Platform string
+	ID       string
+}
+
+// keyOf returns the key of an account
+func keyOf(account models.Account) accountKey {
+	return accountKey{Platform: account.Platform, ID: account.ID}
+}
+
+// accountKeyValues returns the platform and ID pairs of accounts, for use
+// in (platform, account_id) IN ? conditions
+func accountKeyValues(accounts []models.Account) [][]interface{} {
+	values := make([][]interface{}, len(accounts))
+	for i, account := range accounts {
+		values[i] = []interface{}{account.Platform, account.ID}
+	}
+	return values
+}
+
+// parsePlatform parses the platform query parameter naming the platform of
+// a single account, which defaults to the default platform
+func parsePlatform(params url.Values) (string, error) {
+	platform := params.Get("platform")
+	if platform == "" {
+		return validation.DefaultPlatform, nil
+	}
+	return platform, validation.ValidatePlatform(platform)
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/takedown-observer/backend/models"
+)
+
+// postPlatformReport submits a report for an account on a platform and
+// returns the response
+func postPlatformReport(handler *Handler, platform, accountID, name string, countries []string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(models.ReportRequest{
+		ClientID: "123e4567-e89b-12d3-a456-426614174000",
+		Account: models.ReportedAccount{
+			Platform:  platform,
+			ID:        accountID,
+			Name:      name,
+			Countries: countries,
+		},
+		DataFormatVersion: models.DataFormatVersion,
+	})
+	req := httptest.NewRequest("POST", "/api/report", bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+	handler.ReportHandler(w, req)
+	return w
+}
+
+func TestReportHandlerPlatforms(t *testing.T) {
+	db := newTestDB(t)
+	handler := NewHandler(db)
+
+	reports := []struct {
+		platform     string
+		id           string
+		name         string
+		expectedCode int
+	}{
+		{platform: "", id: "1234567890", name: "x_user", expectedCode: http.StatusOK},
+		{platform: "telegram", id: "1234567890", name: "channel_name", expectedCode: http.StatusOK},
+		{platform: "youtube", id: "UCabcdefghijklmnopqrstuv", name: "channel", expectedCode: http.StatusOK},
+		{platform: "youtube", id: "1234567890", name: "channel", expectedCode: http.StatusBadRequest},
+		{platform: "myspace", id: "1234567890", name: "user", expectedCode: http.StatusBadRequest},
+	}
+	for _, report := range reports {
+		if w := postPlatformReport(handler, report.platform, report.id, report.name, []string{"DE"}); w.Code != report.expectedCode {
+			t.Errorf("Report of %s/%s status code = %v, want %v (%s)", report.platform, report.id, w.Code, report.expectedCode, w.Body.String())
+		}
+	}
+
+	t.Run("same ID on two platforms", func(t *testing.T) {
+		var accounts []models.Account
+		db.Order("platform").Find(&accounts, "id = ?", "1234567890")
+		if len(accounts) != 2 || accounts[0].Platform != "telegram" || accounts[1].Platform != "x" {
+			t.Fatalf("Expected separate telegram and x accounts, got %+v", accounts)
+		}
+		if accounts[0].Name != "channel_name" || accounts[1].Name != "x_user" {
+			t.Errorf("Expected each account to keep its own name, got %+v", accounts)
+		}
+	})
+
+	t.Run("listing filter", func(t *testing.T) {
+		tests := []struct {
+			query    string
+			expected int64
+			code     int
+		}{
+			{query: "", expected: 3, code: http.StatusOK},
+			{query: "?platform=x", expected: 1, code: http.StatusOK},
+			{query: "?platform=youtube", expected: 1, code: http.StatusOK},
+			{query: "?platform=tiktok", expected: 0, code: http.StatusOK},
+			{query: "?platform=myspace", code: http.StatusBadRequest},
+		}
+
+		for _, tt := range tests {
+			req := httptest.NewRequest("GET", "/api/accounts"+tt.query, nil)
+			w := httptest.NewRecorder()
+			handler.GetAccountsHandler(w, req)
+
+			if w.Code != tt.code {
+				t.Errorf("GetAccountsHandler(%q) status code = %v, want %v", tt.query, w.Code, tt.code)
+				continue
+			}
+			if tt.code != http.StatusOK {
+				continue
+			}
+
+			var response models.AccountsResponse
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if response.TotalCount != tt.expected {
+				t.Errorf("GetAccountsHandler(%q) total = %d, want %d", tt.query, response.TotalCount, tt.expected)
+			}
+		}
+	})
+
+	t.Run("detail defaults to X", func(t *testing.T) {
+		for platform, expectedName := range map[string]string{"": "x_user", "telegram": "channel_name"} {
+			req := httptest.NewRequest("GET", "/api/accounts/1234567890?platform="+platform, nil)
+			req = mux.SetURLVars(req, map[string]string{"id": "1234567890"})
+			w := httptest.NewRecorder()
+			handler.GetAccountHandler(w, req)
+
+			var detail models.AccountDetail
+			if err := json.NewDecoder(w.Body).Decode(&detail); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if detail.Name != expectedName {
+				t.Errorf("Detail of platform %q has name %q, want %q", platform, detail.Name, expectedName)
+			}
+		}
+	})
+
+	t.Run("stats filter", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/stats/countries?platform=telegram", nil)
+		w := httptest.NewRecorder()
+		handler.GetCountryStatsHandler(w, req)
+
+		var response models.CountryStatsResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(response.Countries) != 1 || response.Countries[0].Accounts != 1 {
+			t.Errorf("Expected 1 telegram account withheld in DE, got %+v", response.Countries)
+		}
+	})
+}
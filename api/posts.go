@@ -0,0 +1,445 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/takedown-observer/backend/models"
+	"github.com/takedown-observer/backend/validation"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReportPostHandler handles POST /api/report/post
+func (h *Handler) ReportPostHandler(w http.ResponseWriter, r *http.Request) {
+	// The raw body is kept since signatures are computed over it
+	body, ok := ReadBody(w, r, MaxReportSize)
+	if !ok {
+		return
+	}
+
+	format, report, err := decodePostReport(body)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err)
+		return
+	}
+	setDeprecationHeaders(w, format)
+
+	post, err := validatePostReport(report)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err)
+		return
+	}
+
+	// Proof of work is checked first so that requests cannot make the
+	// server look up clients without paying for it
+	if err := h.checkProofOfWork(r, 1); err != nil {
+		writeError(w, r, http.StatusForbidden, codeProofOfWork, err)
+		return
+	}
+
+	signer, verifyErr := h.verifySignature(r, body)
+	if err := h.checkSigned(signer, verifyErr, report.ClientID); err != nil {
+		writeError(w, r, http.StatusUnauthorized, codeUnauthorized, err)
+		return
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		return recordPostReport(tx, h.hashClientID(report.ClientID), post)
+	})
+	h.challenges.record(1)
+
+	if err != nil {
+		writeDatabaseError(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
+}
+
+// validatePostReport validates a post report request decoded to the current
+// format and returns the sanitized post it describes
+func validatePostReport(report models.PostReportRequest) (models.WithheldPost, error) {
+	if !validation.ValidateUUID(report.ClientID) {
+		return models.WithheldPost{}, validation.Errorf("client_id", validation.CodeInvalidFormat, "Invalid client ID format")
+	}
+
+	platform := report.Post.Platform
+	if platform == "" {
+		platform = validation.DefaultPlatform
+	}
+
+	if err := validation.ValidatePlatformPost(platform, report.Post.ID, report.Post.AuthorID, report.Post.URL); err != nil {
+		return models.WithheldPost{}, validation.Nest(err, "post")
+	}
+
+	if err := validation.ValidateCountries(report.Post.Countries); err != nil {
+		return models.WithheldPost{}, validation.Nest(err, "post")
+	}
+
+	if report.Post.TextHash != "" {
+		if err := validation.ValidateTextHash(report.Post.TextHash); err != nil {
+			return models.WithheldPost{}, validation.Nest(err, "post")
+		}
+	}
+
+	post := models.WithheldPost{
+		Platform:          platform,
+		ID:                validation.SanitizeString(report.Post.ID),
+		AuthorID:          validation.SanitizeString(report.Post.AuthorID),
+		Countries:         make([]string, len(report.Post.Countries)),
+		TextHash:          report.Post.TextHash,
+		URL:               validation.NormalizePostURL(report.Post.URL),
+		LastReportedAt:    time.Now().UTC(),
+		DataFormatVersion: report.DataFormatVersion,
+	}
+
+	for i, country := range report.Post.Countries {
+		post.Countries[i] = validation.SanitizeString(country)
+	}
+
+	return post, nil
+}
+
+// recordPostReport stores a validated post report from the client with the
+// given hash within the provided transaction. As with accounts, the report
+// count is the number of distinct clients that reported the post.
+func recordPostReport(tx *gorm.DB, clientHash string, post models.WithheldPost) error {
+	var existing models.WithheldPost
+	result := tx.Limit(1).Find(&existing, "platform = ? AND id = ?", post.Platform, post.ID)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		post.ReportCount = 1
+		post.FirstSeenAt = post.LastReportedAt
+
+		if err := tx.Create(&post).Error; err != nil {
+			return err
+		}
+
+		_, err := recordPostReporter(tx, post, clientHash)
+		return err
+	}
+
+	newReporter, err := recordPostReporter(tx, post, clientHash)
+	if err != nil {
+		return err
+	}
+
+	if newReporter {
+		err := tx.Model(&existing).UpdateColumn("report_count", gorm.Expr("report_count + 1")).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	// Reports without a text hash or URL keep the ones reported before
+	existing.Countries = post.Countries
+	existing.LastReportedAt = post.LastReportedAt
+	existing.DataFormatVersion = post.DataFormatVersion
+	if post.TextHash != "" {
+		existing.TextHash = post.TextHash
+	}
+	if post.URL != "" {
+		existing.URL = post.URL
+	}
+
+	return tx.Model(&existing).
+		Select("Countries", "TextHash", "URL", "LastReportedAt", "DataFormatVersion").
+		Updates(&existing).Error
+}
+
+// recordPostReporter links a client to a post it reported and reports
+// whether the client had not reported the post before
+func recordPostReporter(tx *gorm.DB, post models.WithheldPost, clientHash string) (bool, error) {
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.PostReporter{
+		Platform:        post.Platform,
+		PostID:          post.ID,
+		ClientHash:      clientHash,
+		FirstReportedAt: post.LastReportedAt,
+		LastReportedAt:  post.LastReportedAt,
+	})
+	if result.Error != nil || result.RowsAffected > 0 {
+		return result.RowsAffected > 0, result.Error
+	}
+
+	err := tx.Model(&models.PostReporter{}).
+		Where("platform = ? AND post_id = ? AND client_hash = ?", post.Platform, post.ID, clientHash).
+		Update("last_reported_at", post.LastReportedAt).Error
+	return false, err
+}
+
+// authorURL returns the path of the account detail of a post's author
+func authorURL(post models.WithheldPost) string {
+	return "/api/accounts/" + url.PathEscape(post.AuthorID) + "?platform=" + url.QueryEscape(post.Platform)
+}
+
+// postFilters holds the filters accepted by the withheld posts listing
+type postFilters struct {
+	Platform          string
+	AuthorID          string
+	Countries         []string
+	Match             string
+	ExcludedCountries []string
+	ReportedAfter     *time.Time
+	ReportedBefore    *time.Time
+}
+
+// parsePostFilters parses and validates post filters from query parameters
+func parsePostFilters(params url.Values) (postFilters, error) {
+	var filters postFilters
+	var err error
+
+	if filters.Platform = params.Get("platform"); filters.Platform != "" {
+		if err := validation.ValidatePlatform(filters.Platform); err != nil {
+			return filters, err
+		}
+	}
+
+	filters.AuthorID = strings.TrimSpace(params.Get("author"))
+
+	if filters.Countries, err = validation.ParseCountryList("country", params.Get("country")); err != nil {
+		return filters, err
+	}
+
+	if filters.ExcludedCountries, err = validation.ParseCountryList("exclude_country", params.Get("exclude_country")); err != nil {
+		return filters, err
+	}
+
+	filters.Match = params.Get("match")
+	if filters.Match == "" {
+		filters.Match = matchAny
+	}
+	if err := validation.ValidateOneOf("match", filters.Match, matchAny, matchAll); err != nil {
+		return filters, err
+	}
+
+	if value := params.Get("reported_after"); value != "" {
+		reportedAfter, err := validation.ParseTimestamp("reported_after", value)
+		if err != nil {
+			return filters, err
+		}
+		filters.ReportedAfter = &reportedAfter
+	}
+
+	if value := params.Get("reported_before"); value != "" {
+		reportedBefore, err := validation.ParseTimestamp("reported_before", value)
+		if err != nil {
+			return filters, err
+		}
+		filters.ReportedBefore = &reportedBefore
+	}
+
+	if filters.ReportedAfter != nil && filters.ReportedBefore != nil {
+		if err := validation.ValidateTimeRange(*filters.ReportedAfter, *filters.ReportedBefore); err != nil {
+			return filters, err
+		}
+	}
+
+	return filters, nil
+}
+
+// parsePostSort parses and validates the sort and order query parameters of
+// the post listing. Posts have no name or search index, so they sort by the
+// remaining account columns.
+func parsePostSort(params url.Values) (listingSort, error) {
+	sort := listingSort{Table: "withheld_posts", Column: sortLastReportedAt, Order: orderDesc}
+	return parseSort(params, sort, sortLastReportedAt, sortFirstSeenAt, sortReportCount)
+}
+
+// postCountries returns a query over the post country join table selecting
+// posts by platform and ID
+func postCountries(db *gorm.DB) *gorm.DB {
+	return db.Model(&models.PostCountry{}).Select("post_countries.platform, post_countries.post_id")
+}
+
+// apply adds the filters to a post query. Posts match a country filter if
+// they are withheld in any of the countries, or in all of them when matching
+// all, and are excluded if they are withheld in any of the excluded ones.
+// Subqueries select posts by platform and ID.
+func (f postFilters) apply(db *gorm.DB, query *gorm.DB) *gorm.DB {
+	if f.Platform != "" {
+		query = query.Where("withheld_posts.platform = ?", f.Platform)
+	}
+
+	if f.AuthorID != "" {
+		query = query.Where("withheld_posts.author_id = ?", f.AuthorID)
+	}
+
+	if len(f.Countries) > 0 {
+		if f.Match == matchAll {
+			query = query.Where("(withheld_posts.platform, withheld_posts.id) IN (?)", postCountries(db).
+				Where("post_countries.country_code IN ?", f.Countries).
+				Group("post_countries.platform, post_countries.post_id").
+				Having("COUNT(*) = ?", len(f.Countries)))
+		} else {
+			query = query.Where("(withheld_posts.platform, withheld_posts.id) IN (?)", postCountries(db).
+				Where("post_countries.country_code IN ?", f.Countries))
+		}
+	}
+
+	if len(f.ExcludedCountries) > 0 {
+		query = query.Where("(withheld_posts.platform, withheld_posts.id) NOT IN (?)", postCountries(db).
+			Where("post_countries.country_code IN ?", f.ExcludedCountries))
+	}
+
+	if f.ReportedAfter != nil {
+		query = query.Where("withheld_posts.last_reported_at >= ?", f.ReportedAfter.UTC())
+	}
+
+	if f.ReportedBefore != nil {
+		query = query.Where("withheld_posts.last_reported_at < ?", f.ReportedBefore.UTC())
+	}
+
+	return query
+}
+
+// GetPostsHandler handles GET /api/posts
+func (h *Handler) GetPostsHandler(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	filters, err := parsePostFilters(r.URL.Query())
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err)
+		return
+	}
+
+	order, err := parsePostSort(r.URL.Query())
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err)
+		return
+	}
+
+	pageSize, err := validation.ParseLimit(r.URL.Query().Get("limit"), defaultPageSize, maxPageSize)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err)
+		return
+	}
+	offset := (page - 1) * pageSize
+
+	query := filters.apply(h.db, h.db.Model(&models.WithheldPost{}))
+
+	var totalCount int64
+	if err := query.Count(&totalCount).Error; err != nil {
+		writeDatabaseError(w, r)
+		return
+	}
+
+	// As for accounts, a cursor takes precedence over page-based offsets
+	if cursorParam := r.URL.Query().Get("cursor"); cursorParam != "" {
+		cursor, err := decodeCursor(cursorParam)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err)
+			return
+		}
+		query, err = order.after(query, cursor)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err)
+			return
+		}
+		offset = 0
+	}
+
+	// Fetch one extra row to determine whether a next page exists
+	posts := []models.WithheldPost{}
+	if err := order.apply(query).Limit(pageSize + 1).Offset(offset).Find(&posts).Error; err != nil {
+		writeDatabaseError(w, r)
+		return
+	}
+
+	var nextCursor string
+	if len(posts) > pageSize {
+		posts = posts[:pageSize]
+		nextCursor = encodePostCursor(order, posts[pageSize-1])
+	}
+
+	for i := range posts {
+		posts[i].AuthorURL = authorURL(posts[i])
+	}
+
+	response := models.PostsResponse{
+		Posts:       posts,
+		TotalCount:  totalCount,
+		CurrentPage: page,
+		TotalPages:  int((totalCount + int64(pageSize) - 1) / int64(pageSize)),
+		NextCursor:  nextCursor,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// postExportSchema exports withheld posts
+var postExportSchema = exportSchema[models.WithheldPost, models.PostExport]{
+	Filename:  "withheld_posts",
+	CSVHeader: []string{"Platform", "Post ID", "Author ID", "Countries", "Text Hash", "URL", "Report Count", "First Seen At", "Last Reported At", "Data Format Version"},
+	CSVRecord: func(post models.WithheldPost) []string {
+		return []string{
+			post.Platform,
+			post.ID,
+			post.AuthorID,
+			strings.Join(post.Countries, ", "),
+			post.TextHash,
+			post.URL,
+			strconv.Itoa(post.ReportCount),
+			post.FirstSeenAt.Format(time.RFC3339),
+			post.LastReportedAt.Format(time.RFC3339),
+			post.DataFormatVersion,
+		}
+	},
+	Export: exportPost,
+}
+
+// exportPost converts a post to its typed export representation
+func exportPost(post models.WithheldPost) models.PostExport {
+	countries := post.Countries
+	if countries == nil {
+		countries = []string{}
+	}
+
+	return models.PostExport{
+		Platform:          post.Platform,
+		ID:                post.ID,
+		AuthorID:          post.AuthorID,
+		Countries:         countries,
+		TextHash:          post.TextHash,
+		URL:               post.URL,
+		ReportCount:       int64(post.ReportCount),
+		FirstSeenAt:       post.FirstSeenAt.UTC(),
+		LastReportedAt:    post.LastReportedAt.UTC(),
+		DataFormatVersion: post.DataFormatVersion,
+	}
+}
+
+// DownloadPostsHandler handles GET /api/posts/download
+func (h *Handler) DownloadPostsHandler(w http.ResponseWriter, r *http.Request) {
+	format, err := parseExportFormat(r.URL.Query())
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err)
+		return
+	}
+
+	filters, err := parsePostFilters(r.URL.Query())
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err)
+		return
+	}
+
+	order, err := parsePostSort(r.URL.Query())
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err)
+		return
+	}
+
+	streamDownload(w, r, h.db, order.apply(filters.apply(h.db, h.db.Model(&models.WithheldPost{}))), postExportSchema, format)
+}
@@ -0,0 +1,308 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/takedown-observer/backend/models"
+)
+
+const testTextHash = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+// postPostReport submits a post report and returns the response
+func postPostReport(handler *Handler, clientID string, post models.ReportedPost) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(models.PostReportRequest{
+		ClientID:          clientID,
+		Post:              post,
+		DataFormatVersion: models.DataFormatVersion,
+	})
+	req := httptest.NewRequest("POST", "/api/report/post", bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+	handler.ReportPostHandler(w, req)
+	return w
+}
+
+func TestReportPostHandler(t *testing.T) {
+	tests := []struct {
+		name          string
+		post          models.ReportedPost
+		expectedCode  int
+		expectedError string
+		expectedField string
+	}{
+		{
+			name: "valid post",
+			post: models.ReportedPost{
+				ID:        "1893456789012345678",
+				AuthorID:  "1234567890",
+				Countries: []string{"DE"},
+				TextHash:  testTextHash,
+				URL:       "https://x.com/user/status/1893456789012345678",
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name: "valid post without text hash or URL",
+			post: models.ReportedPost{
+				Platform:  "youtube",
+				ID:        "dQw4w9WgXcQ",
+				AuthorID:  "UCabcdefghijklmnopqrstuv",
+				Countries: []string{"RU"},
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:          "invalid post ID",
+			post:          models.ReportedPost{ID: "abc", AuthorID: "1234567890", Countries: []string{"DE"}},
+			expectedCode:  http.StatusBadRequest,
+			expectedError: "post ID contains invalid characters",
+			expectedField: "post.id",
+		},
+		{
+			name:          "missing author",
+			post:          models.ReportedPost{ID: "123", Countries: []string{"DE"}},
+			expectedCode:  http.StatusBadRequest,
+			expectedError: "author account ID cannot be empty",
+			expectedField: "post.author_id",
+		},
+		{
+			name:          "URL on another platform",
+			post:          models.ReportedPost{ID: "123", AuthorID: "1234567890", Countries: []string{"DE"}, URL: "https://example.com/status/123"},
+			expectedCode:  http.StatusBadRequest,
+			expectedError: "post URL is not on the platform",
+			expectedField: "post.url",
+		},
+		{
+			name:          "invalid text hash",
+			post:          models.ReportedPost{ID: "123", AuthorID: "1234567890", Countries: []string{"DE"}, TextHash: "abc"},
+			expectedCode:  http.StatusBadRequest,
+			expectedError: "text hash must be a lowercase hex-encoded SHA-256 hash",
+			expectedField: "post.text_hash",
+		},
+		{
+			name:          "no countries",
+			post:          models.ReportedPost{ID: "123", AuthorID: "1234567890"},
+			expectedCode:  http.StatusBadRequest,
+			expectedError: "countries list cannot be empty",
+			expectedField: "post.countries",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHandler(newTestDB(t))
+
+			w := postPostReport(handler, "123e4567-e89b-12d3-a456-426614174000", tt.post)
+			if w.Code != tt.expectedCode {
+				t.Fatalf("ReportPostHandler() status code = %v, want %v (%s)", w.Code, tt.expectedCode, w.Body.String())
+			}
+			if tt.expectedError == "" {
+				return
+			}
+			problem := decodeProblem(t, w)
+			if problem.Detail != tt.expectedError || problem.Field != tt.expectedField {
+				t.Errorf("ReportPostHandler() error = %q on %q, want %q on %q", problem.Detail, problem.Field, tt.expectedError, tt.expectedField)
+			}
+		})
+	}
+}
+
+func TestReportPostHandlerRepeatedReports(t *testing.T) {
+	db := newTestDB(t)
+	handler := NewHandler(db)
+
+	post := models.ReportedPost{
+		ID:        "1893456789012345678",
+		AuthorID:  "1234567890",
+		Countries: []string{"DE"},
+		TextHash:  testTextHash,
+	}
+	postPostReport(handler, "123e4567-e89b-12d3-a456-426614174000", post)
+
+	post.Countries = []string{"DE", "FR"}
+	post.TextHash = ""
+	postPostReport(handler, "123e4567-e89b-12d3-a456-426614174000", post)
+	postPostReport(handler, "223e4567-e89b-12d3-a456-426614174000", post)
+
+	var stored models.WithheldPost
+	if err := db.First(&stored, "id = ?", post.ID).Error; err != nil {
+		t.Fatalf("Failed to load post: %v", err)
+	}
+	if stored.ReportCount != 2 {
+		t.Errorf("Expected 2 distinct reporters, got %d", stored.ReportCount)
+	}
+	if !reflect.DeepEqual(stored.Countries, []string{"DE", "FR"}) {
+		t.Errorf("Expected countries [DE FR], got %v", stored.Countries)
+	}
+	if stored.TextHash != testTextHash {
+		t.Errorf("Expected the text hash to be kept, got %q", stored.TextHash)
+	}
+
+	post.Countries = []string{"FR"}
+	postPostReport(handler, "223e4567-e89b-12d3-a456-426614174000", post)
+
+	var countries []models.PostCountry
+	db.Find(&countries, "post_id = ?", post.ID)
+	if len(countries) != 1 || countries[0].CountryCode != "FR" {
+		t.Errorf("Expected the country join table to hold only FR, got %v", countries)
+	}
+}
+
+func TestGetPostsHandler(t *testing.T) {
+	handler := NewHandler(newTestDB(t))
+
+	reports := []models.ReportedPost{
+		{ID: "100", AuthorID: "1234567890", Countries: []string{"DE"}},
+		{ID: "200", AuthorID: "1234567890", Countries: []string{"FR", "IN"}},
+		{Platform: "telegram", ID: "-1001234567890/42", AuthorID: "-1001234567890", Countries: []string{"RU"}},
+	}
+	for _, report := range reports {
+		if w := postPostReport(handler, "123e4567-e89b-12d3-a456-426614174000", report); w.Code != http.StatusOK {
+			t.Fatalf("ReportPostHandler() status code = %v (%s)", w.Code, w.Body.String())
+		}
+	}
+
+	tests := []struct {
+		query        string
+		expectedCode int
+		expectedIDs  []string
+	}{
+		{query: "", expectedCode: http.StatusOK, expectedIDs: []string{"-1001234567890/42", "200", "100"}},
+		{query: "?platform=x", expectedCode: http.StatusOK, expectedIDs: []string{"200", "100"}},
+		{query: "?country=IN,DE", expectedCode: http.StatusOK, expectedIDs: []string{"200", "100"}},
+		{query: "?country=FR,IN&match=all", expectedCode: http.StatusOK, expectedIDs: []string{"200"}},
+		{query: "?country=IN,DE&match=all", expectedCode: http.StatusOK, expectedIDs: nil},
+		{query: "?author=-1001234567890", expectedCode: http.StatusOK, expectedIDs: []string{"-1001234567890/42"}},
+		{query: "?limit=1&page=2", expectedCode: http.StatusOK, expectedIDs: []string{"200"}},
+		{query: "?exclude_country=IN,RU", expectedCode: http.StatusOK, expectedIDs: []string{"100"}},
+		{query: "?sort=report_count&order=asc", expectedCode: http.StatusOK, expectedIDs: []string{"-1001234567890/42", "100", "200"}},
+		{query: "?platform=myspace", expectedCode: http.StatusBadRequest},
+		{query: "?country=XYZ", expectedCode: http.StatusBadRequest},
+		{query: "?country=DE&match=some", expectedCode: http.StatusBadRequest},
+		{query: "?sort=name", expectedCode: http.StatusBadRequest},
+		{query: "?cursor=invalid", expectedCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/posts"+tt.query, nil)
+			w := httptest.NewRecorder()
+			handler.GetPostsHandler(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("GetPostsHandler() status code = %v, want %v", w.Code, tt.expectedCode)
+			}
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+
+			var response models.PostsResponse
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+
+			var ids []string
+			for _, post := range response.Posts {
+				ids = append(ids, post.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.expectedIDs) {
+				t.Errorf("Expected posts %v, got %v", tt.expectedIDs, ids)
+			}
+		})
+	}
+
+	t.Run("cursor pages", func(t *testing.T) {
+		var ids []string
+		query := "?limit=2&sort=first_seen_at&order=asc"
+		for page := 0; page < 3; page++ {
+			req := httptest.NewRequest("GET", "/api/posts"+query, nil)
+			w := httptest.NewRecorder()
+			handler.GetPostsHandler(w, req)
+
+			var response models.PostsResponse
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			for _, post := range response.Posts {
+				ids = append(ids, post.ID)
+			}
+			if response.NextCursor == "" {
+				break
+			}
+			query = "?limit=2&sort=first_seen_at&order=asc&cursor=" + response.NextCursor
+		}
+
+		expected := []string{"100", "200", "-1001234567890/42"}
+		if !reflect.DeepEqual(ids, expected) {
+			t.Errorf("Expected posts %v across pages, got %v", expected, ids)
+		}
+	})
+
+	t.Run("links to author detail", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/posts?platform=telegram", nil)
+		w := httptest.NewRecorder()
+		handler.GetPostsHandler(w, req)
+
+		var response models.PostsResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(response.Posts) != 1 || response.Posts[0].AuthorURL != "/api/accounts/-1001234567890?platform=telegram" {
+			t.Errorf("Unexpected author URL in %+v", response.Posts)
+		}
+	})
+}
+
+func TestDownloadPostsHandler(t *testing.T) {
+	handler := NewHandler(newTestDB(t))
+
+	post := models.ReportedPost{
+		ID:        "100",
+		AuthorID:  "1234567890",
+		Countries: []string{"DE", "FR"},
+		TextHash:  testTextHash,
+		URL:       "https://x.com/user/status/100",
+	}
+	postPostReport(handler, "123e4567-e89b-12d3-a456-426614174000", post)
+
+	t.Run("CSV", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/posts/download", nil)
+		w := httptest.NewRecorder()
+		handler.DownloadPostsHandler(w, req)
+
+		if cd := w.Header().Get("Content-Disposition"); cd != "attachment; filename=withheld_posts.csv" {
+			t.Errorf("Unexpected Content-Disposition %s", cd)
+		}
+
+		records, err := csv.NewReader(w.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("Failed to parse CSV: %v", err)
+		}
+		if len(records) != 2 {
+			t.Fatalf("Expected header and 1 post, got %d records", len(records))
+		}
+		expected := []string{"x", "100", "1234567890", "DE, FR", testTextHash, post.URL, "1"}
+		if !reflect.DeepEqual(records[1][:7], expected) {
+			t.Errorf("Expected record %v, got %v", expected, records[1][:7])
+		}
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/posts/download?format=json&platform=x", nil)
+		w := httptest.NewRecorder()
+		handler.DownloadPostsHandler(w, req)
+
+		var exports []models.PostExport
+		if err := json.Unmarshal(w.Body.Bytes(), &exports); err != nil {
+			t.Fatalf("Failed to decode JSON array: %v", err)
+		}
+		if len(exports) != 1 || exports[0].ID != "100" || exports[0].AuthorID != "1234567890" || exports[0].TextHash != testTextHash {
+			t.Errorf("Unexpected export %+v", exports)
+		}
+	})
+}
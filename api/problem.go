@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/takedown-observer/backend/models"
+	"github.com/takedown-observer/backend/validation"
+)
+
+// RequestIDHeader carries the ID the router assigns to each request, which
+// error responses include so they can be matched with server logs
+const RequestIDHeader = "X-Request-ID"
+
+// ProblemContentType is the media type of error responses
+const ProblemContentType = "application/problem+json"
+
+// Error codes of failures other than validation errors
+const (
+	codeInvalidRequest    = "invalid_request"
+	codeInvalidBody       = "invalid_body"
+	codeBodyTooLarge      = "body_too_large"
+	codeUnsupportedFormat = "unsupported_format"
+	codeUnauthorized      = "unauthorized"
+	codeProofOfWork       = "proof_of_work_failed"
+	codeNotFound          = "not_found"
+	codeDatabaseError     = "database_error"
+	codeInternalError     = "internal_error"
+)
+
+// WriteProblem writes an error response as RFC 7807 problem details. Problem
+// types are not documented separately, so the type is about:blank and the
+// title the status text; clients tell errors apart by their code.
+func WriteProblem(w http.ResponseWriter, r *http.Request, status int, code, field, detail string) {
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+
+	json.NewEncoder(w).Encode(models.Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		Code:      code,
+		Field:     field,
+		RequestID: r.Header.Get(RequestIDHeader),
+	})
+}
+
+// writeError writes an error as a problem. Validation errors carry their own
+// code and field; other errors are reported with the given code.
+func writeError(w http.ResponseWriter, r *http.Request, status int, code string, err error) {
+	code, field := problemCode(err, code)
+	WriteProblem(w, r, status, code, field, err.Error())
+}
+
+// writeDatabaseError writes the problem for a failed database query
+func writeDatabaseError(w http.ResponseWriter, r *http.Request) {
+	WriteProblem(w, r, http.StatusInternalServerError, codeDatabaseError, "", "Database error")
+}
+
+// problemCode returns the code and field of an error, falling back to the
+// given code for errors other than validation errors
+func problemCode(err error, code string) (string, string) {
+	var validationErr *validation.Error
+	if errors.As(err, &validationErr) {
+		return validationErr.Code, validationErr.Field
+	}
+	return code, ""
+}
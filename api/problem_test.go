@@ -0,0 +1,187 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/takedown-observer/backend/models"
+	"github.com/takedown-observer/backend/validation"
+)
+
+// decodeProblem decodes a problem details response
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) models.Problem {
+	t.Helper()
+
+	if contentType := w.Header().Get("Content-Type"); contentType != ProblemContentType {
+		t.Fatalf("Expected content type %s, got %s", ProblemContentType, contentType)
+	}
+
+	var problem models.Problem
+	if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+		t.Fatalf("Failed to decode problem: %v", err)
+	}
+	return problem
+}
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		err      error
+		expected models.Problem
+	}{
+		{
+			name:   "validation error",
+			status: http.StatusBadRequest,
+			err:    validation.Nest(validation.Errorf("id", validation.CodeRequired, "account ID cannot be empty"), "account"),
+			expected: models.Problem{
+				Type:      "about:blank",
+				Title:     "Bad Request",
+				Status:    http.StatusBadRequest,
+				Detail:    "account ID cannot be empty",
+				Instance:  "/api/report",
+				Code:      validation.CodeRequired,
+				Field:     "account.id",
+				RequestID: "f47ac10b-58cc-4372-a567-0e02b2c3d479",
+			},
+		},
+		{
+			name:   "other error",
+			status: http.StatusUnauthorized,
+			err:    errors.New("Invalid signature"),
+			expected: models.Problem{
+				Type:      "about:blank",
+				Title:     "Unauthorized",
+				Status:    http.StatusUnauthorized,
+				Detail:    "Invalid signature",
+				Instance:  "/api/report",
+				Code:      codeUnauthorized,
+				RequestID: "f47ac10b-58cc-4372-a567-0e02b2c3d479",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/report", nil)
+			req.Header.Set(RequestIDHeader, "f47ac10b-58cc-4372-a567-0e02b2c3d479")
+			w := httptest.NewRecorder()
+
+			writeError(w, req, tt.status, codeUnauthorized, tt.err)
+
+			if w.Code != tt.status {
+				t.Errorf("writeError() status code = %v, want %v", w.Code, tt.status)
+			}
+			if problem := decodeProblem(t, w); problem != tt.expected {
+				t.Errorf("writeError() problem = %+v, want %+v", problem, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHandlerProblems(t *testing.T) {
+	handler := NewHandler(newTestDB(t))
+
+	tests := []struct {
+		name           string
+		handler        http.HandlerFunc
+		method         string
+		target         string
+		body           string
+		expectedStatus int
+		expectedCode   string
+		expectedField  string
+	}{
+		{
+			name:           "malformed body",
+			handler:        handler.ReportHandler,
+			method:         "POST",
+			target:         "/api/report",
+			body:           `{"client_id":`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   codeInvalidBody,
+		},
+		{
+			name:           "unsupported format",
+			handler:        handler.ReportHandler,
+			method:         "POST",
+			target:         "/api/report",
+			body:           `{"data_format_version":"0.1"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   codeUnsupportedFormat,
+			expectedField:  "data_format_version",
+		},
+		{
+			name:           "invalid client ID",
+			handler:        handler.ReportHandler,
+			method:         "POST",
+			target:         "/api/report",
+			body:           `{"client_id":"invalid","account":{"id":"1","name":"a","countries":["DE"]},"data_format_version":"1.0"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   validation.CodeInvalidFormat,
+			expectedField:  "client_id",
+		},
+		{
+			name:           "invalid account country",
+			handler:        handler.ReportHandler,
+			method:         "POST",
+			target:         "/api/report",
+			body:           `{"client_id":"123e4567-e89b-12d3-a456-426614174000","account":{"id":"1","name":"a","countries":["DEU"]},"data_format_version":"1.0"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   validation.CodeInvalidFormat,
+			expectedField:  "account.countries",
+		},
+		{
+			name:           "invalid query parameter",
+			handler:        handler.GetAccountsHandler,
+			method:         "GET",
+			target:         "/api/accounts?exclude_country=Germany",
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   validation.CodeInvalidFormat,
+			expectedField:  "exclude_country",
+		},
+		{
+			name:           "invalid timestamp",
+			handler:        handler.GetTimeSeriesHandler,
+			method:         "GET",
+			target:         "/api/stats/timeseries?from=yesterday",
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   validation.CodeInvalidFormat,
+			expectedField:  "from",
+		},
+		{
+			name:           "unknown account",
+			handler:        handler.GetAccountHistoryHandler,
+			method:         "GET",
+			target:         "/api/accounts/missing/history",
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   codeNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req *http.Request
+			if tt.body != "" {
+				req = httptest.NewRequest(tt.method, tt.target, bytes.NewBufferString(tt.body))
+			} else {
+				req = httptest.NewRequest(tt.method, tt.target, nil)
+			}
+			w := httptest.NewRecorder()
+			tt.handler(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("status code = %v, want %v (%s)", w.Code, tt.expectedStatus, w.Body.String())
+			}
+
+			problem := decodeProblem(t, w)
+			if problem.Code != tt.expectedCode || problem.Field != tt.expectedField {
+				t.Errorf("problem code = %q on %q, want %q on %q", problem.Code, problem.Field, tt.expectedCode, tt.expectedField)
+			}
+		})
+	}
+}
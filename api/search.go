@@ -0,0 +1,35 @@
+package api
+
+import (
+	"strings"
+)
+
+// ftsPhrase quotes a term as an FTS5 phrase
+func ftsPhrase(term string) string {
+	return `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+}
+
+// searchExpression builds the FTS5 query for a search term. With the trigram
+// tokenizer a phrase matches the term anywhere in an account's ID or names,
+// which also covers prefixes. Fuzzy searches additionally match any of the
+// term's trigrams, so near-misses are found and ranked by how many trigrams
+// they share with the term.
+func searchExpression(term string, fuzzy bool) string {
+	expression := ftsPhrase(term)
+	if !fuzzy {
+		return expression
+	}
+
+	runes := []rune(strings.ToLower(term))
+	seen := make(map[string]bool)
+	for i := 0; i+3 <= len(runes); i++ {
+		trigram := string(runes[i : i+3])
+		if seen[trigram] {
+			continue
+		}
+		seen[trigram] = true
+		expression += " OR " + ftsPhrase(trigram)
+	}
+
+	return expression
+}
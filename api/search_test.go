@@ -0,0 +1,137 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/takedown-observer/backend/models"
+)
+
+func TestSearchExpression(t *testing.T) {
+	tests := []struct {
+		name     string
+		term     string
+		fuzzy    bool
+		expected string
+	}{
+		{
+			name:     "exact",
+			term:     "alice",
+			expected: `"alice"`,
+		},
+		{
+			name:     "quotes are escaped",
+			term:     `al"ice`,
+			expected: `"al""ice"`,
+		},
+		{
+			name:     "fuzzy adds trigrams",
+			term:     "Alice",
+			fuzzy:    true,
+			expected: `"Alice" OR "ali" OR "lic" OR "ice"`,
+		},
+		{
+			name:     "fuzzy skips repeated trigrams",
+			term:     "aaaa",
+			fuzzy:    true,
+			expected: `"aaaa" OR "aaa"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := searchExpression(tt.term, tt.fuzzy); got != tt.expected {
+				t.Errorf("searchExpression(%q, %v) = %s, want %s", tt.term, tt.fuzzy, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetAccountsHandlerSearch(t *testing.T) {
+	db := newTestDB(t)
+	handler := NewHandler(db)
+	clientID := "123e4567-e89b-12d3-a456-426614174000"
+
+	postReport(t, handler, clientID, "1234567890", "journalist_jane", []string{"DE"})
+	postReport(t, handler, clientID, "2222222222", "old_handle", []string{"DE"})
+	postReport(t, handler, clientID, "2222222222", "new_handle", []string{"DE"})
+	postReport(t, handler, clientID, "3333333333", "unrelated", []string{"FR"})
+
+	search := func(t *testing.T, params url.Values) models.AccountsResponse {
+		t.Helper()
+
+		req := httptest.NewRequest("GET", "/api/accounts?"+params.Encode(), nil)
+		w := httptest.NewRecorder()
+		handler.GetAccountsHandler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("GetAccountsHandler() status code = %v, body = %s", w.Code, w.Body.String())
+		}
+
+		var response models.AccountsResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return response
+	}
+
+	t.Run("matches account ID", func(t *testing.T) {
+		response := search(t, url.Values{"search": {"45678"}})
+		if len(response.Accounts) != 1 || response.Accounts[0].ID != "1234567890" {
+			t.Errorf("Expected account 1234567890, got %v", response.Accounts)
+		}
+	})
+
+	t.Run("matches name prefix", func(t *testing.T) {
+		response := search(t, url.Values{"search": {"journ"}})
+		if len(response.Accounts) != 1 || response.Accounts[0].ID != "1234567890" {
+			t.Errorf("Expected account 1234567890, got %v", response.Accounts)
+		}
+	})
+
+	t.Run("matches former name", func(t *testing.T) {
+		response := search(t, url.Values{"search": {"old_handle"}})
+		if len(response.Accounts) != 1 || response.Accounts[0].ID != "2222222222" {
+			t.Errorf("Expected account 2222222222, got %v", response.Accounts)
+		}
+	})
+
+	if !handler.fullTextSearch {
+		t.Skip("SQLite built without FTS5; run with -tags sqlite_fts5 to test full-text search")
+	}
+
+	t.Run("exact search does not match near-misses", func(t *testing.T) {
+		response := search(t, url.Values{"search": {"jurnalist"}})
+		if len(response.Accounts) != 0 {
+			t.Errorf("Expected no accounts, got %v", response.Accounts)
+		}
+	})
+
+	t.Run("fuzzy search ranks near-misses", func(t *testing.T) {
+		response := search(t, url.Values{"search": {"jurnalist"}, "fuzzy": {"true"}})
+		if len(response.Accounts) == 0 || response.Accounts[0].ID != "1234567890" {
+			t.Fatalf("Expected account 1234567890 ranked first, got %v", response.Accounts)
+		}
+		if response.Accounts[0].Score <= 0 {
+			t.Errorf("Expected a positive relevance score, got %v", response.Accounts[0].Score)
+		}
+		for i := 1; i < len(response.Accounts); i++ {
+			if response.Accounts[i].Score > response.Accounts[i-1].Score {
+				t.Errorf("Expected accounts ordered by score, got %v", response.Accounts)
+			}
+		}
+		if response.NextCursor != "" {
+			t.Errorf("Expected no cursor for relevance ordering, got %q", response.NextCursor)
+		}
+	})
+
+	t.Run("explicit sort overrides relevance", func(t *testing.T) {
+		response := search(t, url.Values{"search": {"handle"}, "sort": {"name"}, "order": {"asc"}})
+		if len(response.Accounts) != 1 || response.Accounts[0].Score != 0 {
+			t.Errorf("Expected one unscored account, got %v", response.Accounts)
+		}
+	})
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"time"
+)
+
+// sqliteTimeFormats are the layouts SQLite timestamps are stored in
+var sqliteTimeFormats = []string{
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02T15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// sqlTime scans timestamps produced by SQLite expressions such as MIN and
+// MAX, which lose the column's declared type and are returned as text
+type sqlTime struct {
+	time.Time
+}
+
+// Scan implements sql.Scanner
+func (t *sqlTime) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		t.Time = time.Time{}
+		return nil
+	case time.Time:
+		t.Time = v
+		return nil
+	case []byte:
+		return t.parse(string(v))
+	case string:
+		return t.parse(v)
+	default:
+		return fmt.Errorf("cannot scan %T into timestamp", value)
+	}
+}
+
+// Value implements driver.Valuer
+func (t sqlTime) Value() (driver.Value, error) {
+	return t.Time, nil
+}
+
+func (t *sqlTime) parse(value string) error {
+	for _, format := range sqliteTimeFormats {
+		if parsed, err := time.ParseInLocation(format, value, time.UTC); err == nil {
+			t.Time = parsed.UTC()
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot parse timestamp %q", value)
+}
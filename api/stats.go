@@ -0,0 +1,136 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/takedown-observer/backend/models"
+	"github.com/takedown-observer/backend/validation"
+)
+
+// GetCountryStatsHandler handles GET /api/stats/countries. As in the
+// accounts listing, the reason facet of each country disregards the reason
+// filter.
+func (h *Handler) GetCountryStatsHandler(w http.ResponseWriter, r *http.Request) {
+	now := time.Now().UTC()
+
+	platform := r.URL.Query().Get("platform")
+	if platform != "" {
+		if err := validation.ValidatePlatform(platform); err != nil {
+			writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err)
+			return
+		}
+	}
+
+	reasons, err := validation.ParseReasonList(r.URL.Query().Get("reason"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err)
+		return
+	}
+
+	var rows []struct {
+		CountryCode     string
+		Accounts        int64
+		FirstObservedAt sqlTime
+		LastObservedAt  sqlTime
+		NewLast7Days    int64
+		NewLast30Days   int64
+	}
+
+	query := h.db.Table("account_countries").
+		Select(`account_countries.country_code,
+			COUNT(*) AS accounts,
+			MIN(account_countries.first_seen_at) AS first_observed_at,
+			MAX(account_countries.last_seen_at) AS last_observed_at,
+			SUM(CASE WHEN account_countries.first_seen_at >= ? THEN 1 ELSE 0 END) AS new_last7_days,
+			SUM(CASE WHEN account_countries.first_seen_at >= ? THEN 1 ELSE 0 END) AS new_last30_days`,
+			now.AddDate(0, 0, -7), now.AddDate(0, 0, -30)).
+		Joins("JOIN accounts ON accounts.platform = account_countries.platform AND accounts.id = account_countries.account_id")
+	if platform != "" {
+		query = query.Where("account_countries.platform = ?", platform)
+	}
+	if len(reasons) > 0 {
+		query = query.Where("accounts.reason IN ?", reasons)
+	}
+
+	result := query.Group("account_countries.country_code").
+		Order("accounts DESC, account_countries.country_code ASC").
+		Scan(&rows)
+
+	if result.Error != nil {
+		writeDatabaseError(w, r)
+		return
+	}
+
+	// Reports are counted from observations, which record every report
+	// along with the countries it listed
+	var reportRows []struct {
+		CountryCode  string
+		TotalReports int64
+	}
+
+	reportQuery := h.db.Table("observations, json_each(observations.countries) AS countries").
+		Select("countries.value AS country_code, COUNT(*) AS total_reports").
+		Joins("JOIN accounts ON accounts.platform = observations.platform AND accounts.id = observations.account_id")
+	if platform != "" {
+		reportQuery = reportQuery.Where("observations.platform = ?", platform)
+	}
+	if len(reasons) > 0 {
+		reportQuery = reportQuery.Where("accounts.reason IN ?", reasons)
+	}
+
+	if err := reportQuery.Group("countries.value").Scan(&reportRows).Error; err != nil {
+		writeDatabaseError(w, r)
+		return
+	}
+
+	totalReports := make(map[string]int64)
+	for _, row := range reportRows {
+		totalReports[row.CountryCode] = row.TotalReports
+	}
+
+	var reasonRows []struct {
+		CountryCode string
+		Reason      string
+		Count       int64
+	}
+
+	reasonQuery := h.db.Table("account_countries").
+		Select("account_countries.country_code, accounts.reason, COUNT(*) AS count").
+		Joins("JOIN accounts ON accounts.platform = account_countries.platform AND accounts.id = account_countries.account_id")
+	if platform != "" {
+		reasonQuery = reasonQuery.Where("account_countries.platform = ?", platform)
+	}
+
+	result = reasonQuery.Group("account_countries.country_code, accounts.reason").
+		Order("count DESC, accounts.reason ASC").
+		Scan(&reasonRows)
+
+	if result.Error != nil {
+		writeDatabaseError(w, r)
+		return
+	}
+
+	reasonCounts := make(map[string][]models.ReasonCount)
+	for _, row := range reasonRows {
+		reasonCounts[row.CountryCode] = append(reasonCounts[row.CountryCode], models.ReasonCount{Reason: row.Reason, Count: row.Count})
+	}
+
+	stats := make([]models.CountryStats, len(rows))
+	for i, row := range rows {
+		stats[i] = models.CountryStats{
+			Country:         row.CountryCode,
+			Accounts:        row.Accounts,
+			TotalReports:    totalReports[row.CountryCode],
+			FirstObservedAt: row.FirstObservedAt.Time,
+			LastObservedAt:  row.LastObservedAt.Time,
+			NewLast7Days:    row.NewLast7Days,
+			NewLast30Days:   row.NewLast30Days,
+			Reasons:         reasonCounts[row.CountryCode],
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.CountryStatsResponse{Countries: stats})
+}
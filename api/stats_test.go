@@ -0,0 +1,144 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/takedown-observer/backend/models"
+)
+
+func TestGetCountryStatsHandler(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now().UTC()
+
+	accounts := []models.Account{
+		{ID: "old", Name: "Old", Countries: []string{"DE", "FR"}, LastReportedAt: now.AddDate(0, 0, -60), ReportCount: 2},
+		{ID: "recent", Name: "Recent", Countries: []string{"DE"}, LastReportedAt: now.AddDate(0, 0, -20), ReportCount: 3},
+		{ID: "new", Name: "New", Countries: []string{"DE"}, LastReportedAt: now.AddDate(0, 0, -1), ReportCount: 1},
+	}
+	for _, account := range accounts {
+		if err := db.Create(&account).Error; err != nil {
+			t.Fatalf("Failed to setup test database: %v", err)
+		}
+
+		// Each report is an observation, some by the same client
+		for i := 0; i < account.ReportCount; i++ {
+			observation := models.Observation{AccountID: account.ID, Countries: account.Countries, ObservedAt: account.LastReportedAt}
+			if err := db.Create(&observation).Error; err != nil {
+				t.Fatalf("Failed to setup test database: %v", err)
+			}
+		}
+	}
+	if err := db.Create(&models.Observation{AccountID: "recent", Countries: []string{"DE"}, ObservedAt: now}).Error; err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	handler := NewHandler(db)
+
+	req := httptest.NewRequest("GET", "/api/stats/countries", nil)
+	w := httptest.NewRecorder()
+	handler.GetCountryStatsHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("GetCountryStatsHandler() status code = %v, want %v", w.Code, http.StatusOK)
+	}
+
+	var response models.CountryStatsResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if len(response.Countries) != 2 {
+		t.Fatalf("Expected 2 countries, got %d", len(response.Countries))
+	}
+
+	de := response.Countries[0]
+	if de.Country != "DE" {
+		t.Fatalf("Expected DE to be listed first, got %s", de.Country)
+	}
+	if de.Accounts != 3 {
+		t.Errorf("Expected 3 DE accounts, got %d", de.Accounts)
+	}
+	if de.TotalReports != 7 {
+		t.Errorf("Expected 7 DE reports, got %d", de.TotalReports)
+	}
+	if de.NewLast7Days != 1 {
+		t.Errorf("Expected 1 DE account new in the last 7 days, got %d", de.NewLast7Days)
+	}
+	if de.NewLast30Days != 2 {
+		t.Errorf("Expected 2 DE accounts new in the last 30 days, got %d", de.NewLast30Days)
+	}
+	if !de.FirstObservedAt.Equal(accounts[0].LastReportedAt) {
+		t.Errorf("Expected DE first observed at %v, got %v", accounts[0].LastReportedAt, de.FirstObservedAt)
+	}
+	if !de.LastObservedAt.Equal(accounts[2].LastReportedAt) {
+		t.Errorf("Expected DE last observed at %v, got %v", accounts[2].LastReportedAt, de.LastObservedAt)
+	}
+
+	fr := response.Countries[1]
+	if fr.Country != "FR" || fr.Accounts != 1 || fr.TotalReports != 2 || fr.NewLast30Days != 0 {
+		t.Errorf("Unexpected FR stats %+v", fr)
+	}
+}
+
+func TestGetCountryStatsHandlerReasons(t *testing.T) {
+	db := newTestDB(t)
+
+	accounts := []models.Account{
+		{ID: "a", Name: "Alpha", Countries: []string{"DE", "FR"}, Reason: "legal_demand"},
+		{ID: "b", Name: "Bravo", Countries: []string{"DE"}, Reason: "legal_demand"},
+		{ID: "c", Name: "Charlie", Countries: []string{"DE"}},
+	}
+	for _, account := range accounts {
+		if err := db.Create(&account).Error; err != nil {
+			t.Fatalf("Failed to setup test database: %v", err)
+		}
+	}
+
+	handler := NewHandler(db)
+
+	fetch := func(t *testing.T, params string, expectedCode int) models.CountryStatsResponse {
+		req := httptest.NewRequest("GET", "/api/stats/countries?"+params, nil)
+		w := httptest.NewRecorder()
+		handler.GetCountryStatsHandler(w, req)
+
+		if w.Code != expectedCode {
+			t.Fatalf("GetCountryStatsHandler() status code = %v, want %v", w.Code, expectedCode)
+		}
+
+		var response models.CountryStatsResponse
+		if expectedCode == http.StatusOK {
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+		}
+		return response
+	}
+
+	t.Run("facet", func(t *testing.T) {
+		response := fetch(t, "", http.StatusOK)
+		if len(response.Countries) != 2 {
+			t.Fatalf("Expected 2 countries, got %d", len(response.Countries))
+		}
+
+		expected := []models.ReasonCount{{Reason: "legal_demand", Count: 2}, {Reason: "unspecified", Count: 1}}
+		if !reflect.DeepEqual(response.Countries[0].Reasons, expected) {
+			t.Errorf("Expected DE reasons %v, got %v", expected, response.Countries[0].Reasons)
+		}
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		response := fetch(t, "reason=unspecified", http.StatusOK)
+		if len(response.Countries) != 1 || response.Countries[0].Country != "DE" || response.Countries[0].Accounts != 1 {
+			t.Errorf("Unexpected stats %+v", response.Countries)
+		}
+	})
+
+	t.Run("invalid reason", func(t *testing.T) {
+		fetch(t, "reason=copyright", http.StatusBadRequest)
+	})
+}
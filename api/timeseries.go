@@ -0,0 +1,219 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/takedown-observer/backend/models"
+	"github.com/takedown-observer/backend/validation"
+	"gorm.io/gorm"
+)
+
+// Time series intervals
+const (
+	intervalDay   = "day"
+	intervalWeek  = "week"
+	intervalMonth = "month"
+)
+
+// Time series limits
+const (
+	defaultTimeSeriesDays = 30
+	maxTimeSeriesPoints   = 1000
+)
+
+// bucketExpressions are the SQLite expressions mapping an event timestamp to
+// the start date of its interval. Weeks start on Monday.
+var bucketExpressions = map[string]string{
+	intervalDay:   "date(occurred_at)",
+	intervalWeek:  "date(occurred_at, 'weekday 0', '-6 days')",
+	intervalMonth: "date(occurred_at, 'start of month')",
+}
+
+// bucketStart returns the start of the interval containing t
+func bucketStart(interval string, t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch interval {
+	case intervalWeek:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case intervalMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day
+	}
+}
+
+// nextBucket returns the start of the interval following the one at start
+func nextBucket(interval string, start time.Time) time.Time {
+	switch interval {
+	case intervalWeek:
+		return start.AddDate(0, 0, 7)
+	case intervalMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// GetTimeSeriesHandler handles GET /api/stats/timeseries
+func (h *Handler) GetTimeSeriesHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	interval := params.Get("interval")
+	if interval == "" {
+		interval = intervalDay
+	}
+	if err := validation.ValidateOneOf("interval", interval, intervalDay, intervalWeek, intervalMonth); err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err)
+		return
+	}
+
+	country := params.Get("country")
+	if country != "" {
+		if err := validation.ValidateCountryCode(country); err != nil {
+			writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err)
+			return
+		}
+	}
+
+	platform := params.Get("platform")
+	if platform != "" {
+		if err := validation.ValidatePlatform(platform); err != nil {
+			writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err)
+			return
+		}
+	}
+
+	reason := params.Get("reason")
+	if reason != "" {
+		if err := validation.ValidateReason(reason); err != nil {
+			writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err)
+			return
+		}
+	}
+
+	to := time.Now().UTC()
+	if value := params.Get("to"); value != "" {
+		parsed, err := validation.ParseTimestamp("to", value)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err)
+			return
+		}
+		to = parsed.UTC()
+	}
+
+	from := to.AddDate(0, 0, -defaultTimeSeriesDays)
+	if value := params.Get("from"); value != "" {
+		parsed, err := validation.ParseTimestamp("from", value)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err)
+			return
+		}
+		from = parsed.UTC()
+	}
+
+	if err := validation.ValidateTimeRange(from, to); err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err)
+		return
+	}
+
+	includeLifted := false
+	if value := params.Get("include_lifted"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, codeInvalidRequest, validation.Errorf("include_lifted", validation.CodeInvalidFormat, "include_lifted must be true or false"))
+			return
+		}
+		includeLifted = parsed
+	}
+
+	// Build the zero-filled series before querying so oversized ranges are
+	// rejected without touching the database
+	var points []models.TimeSeriesPoint
+	index := make(map[string]int)
+	for start := bucketStart(interval, from); start.Before(to); start = nextBucket(interval, start) {
+		if len(points) == maxTimeSeriesPoints {
+			writeError(w, r, http.StatusBadRequest, codeInvalidRequest, validation.Errorf("", validation.CodeOutOfRange, "Time range spans more than %d intervals", maxTimeSeriesPoints))
+			return
+		}
+		point := models.TimeSeriesPoint{Start: start}
+		if includeLifted {
+			point.Lifted = new(int64)
+		}
+		index[start.Format("2006-01-02")] = len(points)
+		points = append(points, point)
+	}
+
+	eventTypes := []string{models.EventImposed}
+	if includeLifted {
+		eventTypes = append(eventTypes, models.EventLifted)
+	}
+
+	// The reason facet counts imposed events regardless of the reason filter
+	events := h.db.Model(&models.CountryEvent{}).
+		Where("occurred_at >= ? AND occurred_at < ?", from, to)
+	if country != "" {
+		events = events.Where("country_code = ?", country)
+	}
+	if platform != "" {
+		events = events.Where("platform = ?", platform)
+	}
+
+	reasons := []models.ReasonCount{}
+	err := events.Session(&gorm.Session{}).
+		Select("reason, COUNT(*) AS count").
+		Where("type = ?", models.EventImposed).
+		Group("reason").
+		Order("count DESC, reason ASC").
+		Scan(&reasons).Error
+	if err != nil {
+		writeDatabaseError(w, r)
+		return
+	}
+
+	query := events.Session(&gorm.Session{}).
+		Select(bucketExpressions[interval]+" AS bucket, type, COUNT(*) AS count").
+		Where("type IN ?", eventTypes)
+	if reason != "" {
+		query = query.Where("reason = ?", reason)
+	}
+
+	var rows []struct {
+		Bucket string
+		Type   string
+		Count  int64
+	}
+	if err := query.Group("bucket, type").Scan(&rows).Error; err != nil {
+		writeDatabaseError(w, r)
+		return
+	}
+
+	for _, row := range rows {
+		i, ok := index[row.Bucket]
+		if !ok {
+			continue
+		}
+		if row.Type == models.EventLifted {
+			*points[i].Lifted = row.Count
+		} else {
+			points[i].Imposed = row.Count
+		}
+	}
+
+	response := models.TimeSeriesResponse{
+		Country:  country,
+		Reason:   reason,
+		Interval: interval,
+		From:     from,
+		To:       to,
+		Points:   points,
+		Reasons:  reasons,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
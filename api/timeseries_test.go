@@ -0,0 +1,127 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/takedown-observer/backend/models"
+)
+
+func TestBucketStart(t *testing.T) {
+	// Thursday
+	at := time.Date(2025, 2, 20, 14, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		interval string
+		expected time.Time
+	}{
+		{intervalDay, time.Date(2025, 2, 20, 0, 0, 0, 0, time.UTC)},
+		{intervalWeek, time.Date(2025, 2, 17, 0, 0, 0, 0, time.UTC)},
+		{intervalMonth, time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.interval, func(t *testing.T) {
+			if got := bucketStart(tt.interval, at); !got.Equal(tt.expected) {
+				t.Errorf("bucketStart(%s) = %v, want %v", tt.interval, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetTimeSeriesHandler(t *testing.T) {
+	db := newTestDB(t)
+	day := func(d, hour int) time.Time { return time.Date(2025, 2, d, hour, 0, 0, 0, time.UTC) }
+
+	events := []models.CountryEvent{
+		{AccountID: "a", CountryCode: "DE", Type: models.EventImposed, OccurredAt: day(17, 9)},
+		{AccountID: "b", CountryCode: "DE", Type: models.EventImposed, OccurredAt: day(17, 18)},
+		{AccountID: "c", CountryCode: "DE", Type: models.EventImposed, OccurredAt: day(19, 12)},
+		{AccountID: "a", CountryCode: "DE", Type: models.EventLifted, OccurredAt: day(19, 13)},
+		{AccountID: "d", CountryCode: "FR", Type: models.EventImposed, OccurredAt: day(18, 12)},
+		{AccountID: "e", CountryCode: "DE", Type: models.EventImposed, Reason: "legal_demand", OccurredAt: day(24, 12)},
+	}
+	if err := db.Create(&events).Error; err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	handler := NewHandler(db)
+
+	fetch := func(t *testing.T, params string, expectedCode int) models.TimeSeriesResponse {
+		req := httptest.NewRequest("GET", "/api/stats/timeseries?"+params, nil)
+		w := httptest.NewRecorder()
+		handler.GetTimeSeriesHandler(w, req)
+
+		if w.Code != expectedCode {
+			t.Fatalf("GetTimeSeriesHandler() status code = %v, want %v (%s)", w.Code, expectedCode, w.Body.String())
+		}
+
+		var response models.TimeSeriesResponse
+		if expectedCode == http.StatusOK {
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+		}
+		return response
+	}
+
+	t.Run("daily series is zero filled", func(t *testing.T) {
+		response := fetch(t, "country=DE&from=2025-02-17T00:00:00Z&to=2025-02-21T00:00:00Z", http.StatusOK)
+
+		expected := []int64{2, 0, 1, 0}
+		if len(response.Points) != len(expected) {
+			t.Fatalf("Expected %d points, got %d", len(expected), len(response.Points))
+		}
+		for i, point := range response.Points {
+			if !point.Start.Equal(day(17+i, 0)) {
+				t.Errorf("Expected point %d to start at %v, got %v", i, day(17+i, 0), point.Start)
+			}
+			if point.Imposed != expected[i] {
+				t.Errorf("Expected %d imposed on point %d, got %d", expected[i], i, point.Imposed)
+			}
+			if point.Lifted != nil {
+				t.Errorf("Expected lifted counts to be omitted, got %d", *point.Lifted)
+			}
+		}
+	})
+
+	t.Run("weekly series with lifts", func(t *testing.T) {
+		response := fetch(t, "interval=week&include_lifted=true&from=2025-02-17T00:00:00Z&to=2025-03-01T00:00:00Z", http.StatusOK)
+
+		if len(response.Points) != 2 {
+			t.Fatalf("Expected 2 points, got %d", len(response.Points))
+		}
+		if response.Points[0].Imposed != 4 || response.Points[0].Lifted == nil || *response.Points[0].Lifted != 1 {
+			t.Errorf("Unexpected first week %+v", response.Points[0])
+		}
+		if response.Points[1].Imposed != 1 || response.Points[1].Lifted == nil || *response.Points[1].Lifted != 0 {
+			t.Errorf("Unexpected second week %+v", response.Points[1])
+		}
+	})
+
+	t.Run("reason filter and facet", func(t *testing.T) {
+		response := fetch(t, "interval=week&reason=legal_demand&from=2025-02-17T00:00:00Z&to=2025-03-01T00:00:00Z", http.StatusOK)
+
+		if response.Points[0].Imposed != 0 || response.Points[1].Imposed != 1 {
+			t.Errorf("Expected only the legal demand imposition to be counted, got %+v", response.Points)
+		}
+
+		expected := []models.ReasonCount{{Reason: "unspecified", Count: 4}, {Reason: "legal_demand", Count: 1}}
+		if !reflect.DeepEqual(response.Reasons, expected) {
+			t.Errorf("Expected reasons %v, got %v", expected, response.Reasons)
+		}
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		fetch(t, "interval=hour", http.StatusBadRequest)
+		fetch(t, "country=Germany", http.StatusBadRequest)
+		fetch(t, "from=2025-02-21T00:00:00Z&to=2025-02-17T00:00:00Z", http.StatusBadRequest)
+		fetch(t, "from=2000-01-01T00:00:00Z&to=2025-01-01T00:00:00Z", http.StatusBadRequest)
+		fetch(t, "include_lifted=maybe", http.StatusBadRequest)
+		fetch(t, "reason=copyright", http.StatusBadRequest)
+	})
+}
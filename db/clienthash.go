@@ -0,0 +1,105 @@
+package db
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// HashClientID returns the hash a client ID is stored under. Client IDs are
+// hashed with SHA-256 and then keyed with HMAC-SHA256, so that hashes cannot
+// be linked to client IDs without the server secret and unkeyed hashes
+// stored by earlier versions can be migrated. The key must not change once
+// hashes have been stored.
+func HashClientID(key []byte, clientID string) string {
+	sum := sha256.Sum256([]byte(clientID))
+	return keyClientHash(key, hex.EncodeToString(sum[:]))
+}
+
+// keyClientHash returns the keyed form of an unkeyed client ID hash
+func keyClientHash(key []byte, hash string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(hash))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// migrateClientHashes keys the unkeyed client hashes stored with
+// observations and confirmations by earlier versions, and links reporters
+// to accounts from their observations. It runs once, when the
+// account_reporters table is created.
+func migrateClientHashes(db *gorm.DB, key []byte) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, table := range []string{"observations", "country_confirmations"} {
+			var hashes []string
+			err := tx.Table(table).Distinct("client_hash").Where("client_hash <> ''").Pluck("client_hash", &hashes).Error
+			if err != nil {
+				return err
+			}
+
+			for _, hash := range hashes {
+				err := tx.Table(table).Where("client_hash = ?", hash).Update("client_hash", keyClientHash(key, hash)).Error
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		err := tx.Exec(`UPDATE country_confirmations SET last_confirmed_at = (
+			SELECT MAX(observed_at) FROM observations
+			WHERE observations.platform = country_confirmations.platform
+			AND observations.account_id = country_confirmations.account_id
+			AND observations.client_hash = country_confirmations.client_hash)`).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Exec(`INSERT OR IGNORE INTO account_reporters (platform, account_id, client_hash, first_reported_at, last_reported_at)
+			SELECT platform, account_id, client_hash, MIN(observed_at), MAX(observed_at)
+			FROM observations WHERE client_hash <> '' GROUP BY platform, account_id, client_hash`).Error
+	})
+}
+
+// migrateReportedBy moves reporters from the legacy reported_by column of
+// accounts, which holds raw client IDs, into the account_reporters table and
+// drops the column
+func migrateReportedBy(db *gorm.DB, key []byte) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var accounts []struct {
+			Platform   string
+			ID         string
+			ReportedBy sql.NullString
+		}
+		err := tx.Table("accounts").
+			Select("platform, id, reported_by").
+			Where("reported_by IS NOT NULL").
+			Scan(&accounts).Error
+		if err != nil {
+			return err
+		}
+
+		for _, account := range accounts {
+			var clientIDs []string
+			if account.ReportedBy.String != "" {
+				if err := json.Unmarshal([]byte(account.ReportedBy.String), &clientIDs); err != nil {
+					return fmt.Errorf("reading reporters of account %s: %w", account.ID, err)
+				}
+			}
+
+			for _, clientID := range clientIDs {
+				err := tx.Exec(`INSERT OR IGNORE INTO account_reporters (platform, account_id, client_hash, first_reported_at, last_reported_at)
+					SELECT platform, id, ?, last_reported_at, last_reported_at FROM accounts WHERE platform = ? AND id = ?`,
+					HashClientID(key, clientID), account.Platform, account.ID).Error
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		return tx.Exec("ALTER TABLE accounts DROP COLUMN reported_by").Error
+	})
+}
@@ -10,16 +10,166 @@ type DB struct {
 	*gorm.DB
 }
 
-func New(filepath string) (*gorm.DB, error) {
+// New opens the database at filepath and migrates it. Client IDs are stored
+// as hashes keyed with clientHashKey.
+func New(filepath string, clientHashKey []byte) (*gorm.DB, error) {
 	db, err := gorm.Open(sqlite.Open(filepath), &gorm.Config{})
 	if err != nil {
 		return nil, err
 	}
 
-	err = db.AutoMigrate(&models.Account{})
-	if err != nil {
+	if err := Migrate(db, clientHashKey); err != nil {
 		return nil, err
 	}
 
 	return db, nil
 }
+
+// Migrate creates or updates the schema and backfills tables added after
+// data was first collected. Client IDs found in existing data are hashed with
+// clientHashKey.
+func Migrate(db *gorm.DB, clientHashKey []byte) error {
+	backfillCountries := !db.Migrator().HasTable(&models.AccountCountry{})
+	backfillPostCountries := !db.Migrator().HasTable(&models.PostCountry{})
+	backfillNames := !db.Migrator().HasTable(&models.AccountName{})
+	backfillEvents := !db.Migrator().HasTable(&models.CountryEvent{})
+	backfillConfirmations := !db.Migrator().HasTable(&models.CountryConfirmation{})
+	backfillReporters := !db.Migrator().HasTable(&models.AccountReporter{})
+	backfillFirstSeen := db.Migrator().HasTable(&models.Account{}) &&
+		!db.Migrator().HasColumn(&models.Account{}, "FirstSeenAt")
+	rekeyPlatforms := db.Migrator().HasTable(&models.Account{}) &&
+		!db.Migrator().HasColumn(&models.Account{}, "Platform")
+
+	err := db.AutoMigrate(
+		&models.Account{},
+		&models.AccountCountry{},
+		&models.AccountName{},
+		&models.AccountReporter{},
+		&models.CountryConfirmation{},
+		&models.PurgedConfirmation{},
+		&models.Observation{},
+		&models.CountryEvent{},
+		&models.Client{},
+		&models.WithheldPost{},
+		&models.PostCountry{},
+		&models.PostReporter{},
+	)
+	if err != nil {
+		return err
+	}
+
+	if backfillCountries {
+		// Populate the country join table from the countries stored on
+		// each account
+		err := db.Exec(`INSERT OR IGNORE INTO account_countries (platform, account_id, country_code, first_seen_at, last_seen_at)
+			SELECT accounts.platform, accounts.id, countries.value, accounts.last_reported_at, accounts.last_reported_at
+			FROM accounts, json_each(accounts.countries) AS countries`).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	if backfillPostCountries {
+		// Populate the post country join table from the countries stored on
+		// each post
+		err := db.Exec(`INSERT OR IGNORE INTO post_countries (platform, post_id, country_code)
+			SELECT withheld_posts.platform, withheld_posts.id, countries.value
+			FROM withheld_posts, json_each(withheld_posts.countries) AS countries`).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	if backfillEvents {
+		// Countries withheld before events were recorded are taken to have
+		// been imposed when they were first seen, so time series and lifts
+		// cover them. Their reason is unknown.
+		err := db.Exec(`INSERT INTO country_events (platform, account_id, country_code, type, occurred_at)
+			SELECT platform, account_id, country_code, ?, first_seen_at FROM account_countries`,
+			models.EventImposed).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	if backfillNames {
+		// Recover former names from observations, then add the current
+		// name of accounts reported before observations were recorded
+		err := db.Exec(`INSERT OR IGNORE INTO account_names (platform, account_id, name, first_seen_at, last_seen_at)
+			SELECT platform, account_id, name, MIN(observed_at), MAX(observed_at)
+			FROM observations WHERE name <> '' GROUP BY platform, account_id, name`).Error
+		if err != nil {
+			return err
+		}
+
+		err = db.Exec(`INSERT OR IGNORE INTO account_names (platform, account_id, name, first_seen_at, last_seen_at)
+			SELECT platform, id, name, last_reported_at, last_reported_at FROM accounts`).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	if backfillReporters {
+		if err := migrateClientHashes(db, clientHashKey); err != nil {
+			return err
+		}
+	}
+
+	if db.Migrator().HasColumn("accounts", "reported_by") {
+		if err := migrateReportedBy(db, clientHashKey); err != nil {
+			return err
+		}
+	}
+
+	if backfillConfirmations {
+		// Observations record which client saw which countries. Accounts
+		// reported before observations were recorded have no
+		// confirmations, since their reporters' countries are unknown.
+		err := db.Exec(`INSERT OR IGNORE INTO country_confirmations (platform, account_id, country_code, client_hash, last_confirmed_at)
+			SELECT observations.platform, observations.account_id, countries.value, observations.client_hash, MAX(observations.observed_at)
+			FROM observations, json_each(observations.countries) AS countries
+			WHERE observations.client_hash <> ''
+			GROUP BY observations.platform, observations.account_id, countries.value, observations.client_hash`).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	if rekeyPlatforms {
+		if err := migratePlatformKeys(db); err != nil {
+			return err
+		}
+	}
+
+	if db.Migrator().HasColumn("clients", "secret") {
+		// Clients registered with shared secrets, which were stored in
+		// plaintext, must register again with a public key
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("public_key IS NULL OR public_key = ''").Delete(&models.Client{}).Error; err != nil {
+				return err
+			}
+			return tx.Exec("ALTER TABLE clients DROP COLUMN secret").Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := migrateSearchIndex(db); err != nil {
+		return err
+	}
+
+	if backfillFirstSeen {
+		// Use the earliest observation where available, otherwise the
+		// only timestamp recorded for the account
+		err := db.Exec(`UPDATE accounts SET first_seen_at = COALESCE(
+			(SELECT MIN(observed_at) FROM observations
+				WHERE observations.platform = accounts.platform AND observations.account_id = accounts.id),
+			last_reported_at)`).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -1,9 +1,16 @@
 package db
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/takedown-observer/backend/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
 )
 
 func TestNew(t *testing.T) {
@@ -17,7 +24,7 @@ func TestNew(t *testing.T) {
 	dbPath := filepath.Join(tmpDir, "test.db")
 
 	// Test database creation
-	_, err = New(dbPath)
+	_, err = New(dbPath, nil)
 	if err != nil {
 		t.Fatalf("Failed to create database: %v", err)
 	}
@@ -29,8 +36,309 @@ func TestNew(t *testing.T) {
 
 	// Test with invalid path
 	invalidPath := filepath.Join(tmpDir, "nonexistent", "test.db")
-	_, err = New(invalidPath)
+	_, err = New(invalidPath, nil)
 	if err == nil {
 		t.Error("Expected error for invalid database path")
 	}
 }
+
+func TestMigrateBackfillsLegacyAccounts(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "takedown-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(tmpDir, "test.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+
+	// Simulate a database created before the country join table existed
+	if err := db.Exec(`CREATE TABLE accounts (id text PRIMARY KEY, name text, countries text,
+		last_reported_at datetime, report_count integer, reported_by text, data_format_version text)`).Error; err != nil {
+		t.Fatalf("Failed to create legacy table: %v", err)
+	}
+	if err := db.Exec(`INSERT INTO accounts VALUES ('account1', 'Account1', '["DE","FR"]', '2025-02-20 12:00:00+00:00', 1, '[]', '1.0')`).Error; err != nil {
+		t.Fatalf("Failed to insert legacy account: %v", err)
+	}
+
+	if err := Migrate(db, nil); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	var countries []models.AccountCountry
+	db.Order("country_code").Find(&countries)
+	if len(countries) != 2 || countries[0].CountryCode != "DE" || countries[1].CountryCode != "FR" {
+		t.Errorf("Expected backfilled countries DE and FR, got %v", countries)
+	}
+
+	var events []models.CountryEvent
+	db.Order("country_code").Find(&events)
+	if len(events) != 2 || events[0].CountryCode != "DE" || events[1].CountryCode != "FR" {
+		t.Fatalf("Expected backfilled events for DE and FR, got %v", events)
+	}
+	for _, event := range events {
+		if event.Type != models.EventImposed || event.Platform != "x" || event.Reason != "unspecified" ||
+			!event.OccurredAt.Equal(time.Date(2025, 2, 20, 12, 0, 0, 0, time.UTC)) {
+			t.Errorf("Expected an unspecified imposition at first sight, got %+v", event)
+		}
+	}
+
+	var names []models.AccountName
+	db.Find(&names)
+	if len(names) != 1 || names[0].AccountID != "account1" || names[0].Name != "Account1" {
+		t.Errorf("Expected backfilled name Account1, got %v", names)
+	}
+
+	var account models.Account
+	db.First(&account, "id = ?", "account1")
+	if !account.FirstSeenAt.Equal(account.LastReportedAt) || account.FirstSeenAt.IsZero() {
+		t.Errorf("Expected first seen to be backfilled from last reported, got %v", account.FirstSeenAt)
+	}
+}
+
+func newLegacyTestDB(t *testing.T) *gorm.DB {
+	tmpDir, err := os.MkdirTemp("", "takedown-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(tmpDir) })
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(tmpDir, "test.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	return db
+}
+
+func TestMigrateHashesClientIDs(t *testing.T) {
+	db := newLegacyTestDB(t)
+	key := []byte("secret")
+
+	// Simulate a database storing raw client IDs on accounts and unkeyed
+	// client hashes on observations
+	if err := db.Exec(`CREATE TABLE accounts (id text PRIMARY KEY, name text, countries text,
+		last_reported_at datetime, report_count integer, reported_by text, data_format_version text)`).Error; err != nil {
+		t.Fatalf("Failed to create legacy table: %v", err)
+	}
+	if err := db.Exec(`INSERT INTO accounts VALUES ('account1', 'Account1', '["DE"]', '2025-02-20 12:00:00+00:00', 2, '["client1","client2"]', '1.0')`).Error; err != nil {
+		t.Fatalf("Failed to insert legacy account: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Observation{}); err != nil {
+		t.Fatalf("Failed to create observations table: %v", err)
+	}
+	observedAt := time.Date(2025, 2, 20, 11, 0, 0, 0, time.UTC)
+	unkeyed := sha256.Sum256([]byte("client1"))
+	observation := models.Observation{
+		AccountID:  "account1",
+		ClientHash: hex.EncodeToString(unkeyed[:]),
+		Countries:  []string{"DE"},
+		ObservedAt: observedAt,
+	}
+	if err := db.Create(&observation).Error; err != nil {
+		t.Fatalf("Failed to insert legacy observation: %v", err)
+	}
+
+	if err := Migrate(db, key); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	client1, client2 := HashClientID(key, "client1"), HashClientID(key, "client2")
+
+	db.First(&observation)
+	if observation.ClientHash != client1 {
+		t.Errorf("Expected observation hash to be keyed, got %s", observation.ClientHash)
+	}
+
+	var reporters []models.AccountReporter
+	db.Order("first_reported_at").Find(&reporters)
+	if len(reporters) != 2 {
+		t.Fatalf("Expected 2 reporters, got %v", reporters)
+	}
+	if reporters[0].ClientHash != client1 || !reporters[0].FirstReportedAt.Equal(observedAt) {
+		t.Errorf("Expected client1 to be migrated from its observation, got %+v", reporters[0])
+	}
+	if reporters[1].ClientHash != client2 {
+		t.Errorf("Expected client2 to be migrated from the account, got %+v", reporters[1])
+	}
+
+	var confirmations []models.CountryConfirmation
+	db.Find(&confirmations)
+	if len(confirmations) != 1 || confirmations[0].ClientHash != client1 || !confirmations[0].LastConfirmedAt.Equal(observedAt) {
+		t.Errorf("Expected a keyed confirmation from client1, got %v", confirmations)
+	}
+
+	if db.Migrator().HasColumn("accounts", "reported_by") {
+		t.Errorf("Expected the reported_by column holding raw client IDs to be dropped")
+	}
+
+	var account models.Account
+	if err := db.First(&account, "id = ?", "account1").Error; err != nil || account.ReportCount != 2 {
+		t.Errorf("Expected account to be kept with its report count, got %+v (%v)", account, err)
+	}
+}
+
+func TestPurgeReporters(t *testing.T) {
+	db := newLegacyTestDB(t)
+	if err := Migrate(db, nil); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	cutoff := time.Date(2025, 2, 20, 12, 0, 0, 0, time.UTC)
+	old, recent := cutoff.Add(-time.Hour), cutoff.Add(time.Hour)
+
+	setup := []interface{}{
+		&[]models.AccountReporter{
+			{AccountID: "account1", ClientHash: "a", FirstReportedAt: old, LastReportedAt: old},
+			{AccountID: "account1", ClientHash: "b", FirstReportedAt: old, LastReportedAt: recent},
+		},
+		&[]models.CountryConfirmation{
+			{AccountID: "account1", CountryCode: "DE", ClientHash: "a", LastConfirmedAt: old},
+			{AccountID: "account1", CountryCode: "DE", ClientHash: "b", LastConfirmedAt: recent},
+		},
+		&[]models.Observation{
+			{AccountID: "account1", ClientHash: "a", ObservedAt: old},
+			{AccountID: "account1", ClientHash: "b", ObservedAt: recent},
+		},
+	}
+	for _, rows := range setup {
+		if err := db.Create(rows).Error; err != nil {
+			t.Fatalf("Failed to setup test database: %v", err)
+		}
+	}
+
+	// Purging twice must not count purged confirmations again
+	for i := 0; i < 2; i++ {
+		if err := PurgeReporters(db, cutoff); err != nil {
+			t.Fatalf("PurgeReporters() error = %v", err)
+		}
+	}
+
+	var reporters []models.AccountReporter
+	db.Find(&reporters)
+	if len(reporters) != 1 || reporters[0].ClientHash != "b" {
+		t.Errorf("Expected only the recent reporter to remain, got %v", reporters)
+	}
+
+	var confirmations []models.CountryConfirmation
+	db.Find(&confirmations)
+	if len(confirmations) != 1 || confirmations[0].ClientHash != "b" {
+		t.Errorf("Expected only the recent confirmation to remain, got %v", confirmations)
+	}
+
+	var purged []models.PurgedConfirmation
+	db.Find(&purged)
+	if len(purged) != 1 || purged[0].CountryCode != "DE" || purged[0].Count != 1 {
+		t.Errorf("Expected 1 purged DE confirmation, got %v", purged)
+	}
+
+	var hashes []string
+	db.Model(&models.Observation{}).Order("observed_at").Pluck("client_hash", &hashes)
+	if len(hashes) != 2 || hashes[0] != "" || hashes[1] != "b" {
+		t.Errorf("Expected old observation hashes to be cleared, got %v", hashes)
+	}
+}
+
+func TestMigrateKeysAccountsByPlatform(t *testing.T) {
+	db := newLegacyTestDB(t)
+
+	// Simulate a database keying accounts and their countries by ID alone
+	statements := []string{
+		`CREATE TABLE accounts (id text PRIMARY KEY, name text, countries text, first_seen_at datetime,
+			last_reported_at datetime, report_count integer, data_format_version text)`,
+		`CREATE INDEX idx_accounts_last_reported_at ON accounts (last_reported_at)`,
+		`CREATE TABLE account_countries (account_id text, country_code text, first_seen_at datetime,
+			last_seen_at datetime, PRIMARY KEY (account_id, country_code))`,
+		`CREATE INDEX idx_account_countries_country ON account_countries (country_code, account_id)`,
+		`INSERT INTO accounts VALUES ('account1', 'Account1', '["DE"]', '2025-02-20 12:00:00+00:00',
+			'2025-02-20 12:00:00+00:00', 1, '1.0')`,
+		`INSERT INTO account_countries VALUES ('account1', 'DE', '2025-02-20 12:00:00+00:00', '2025-02-20 12:00:00+00:00')`,
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatalf("Failed to create legacy tables: %v", err)
+		}
+	}
+
+	if err := Migrate(db, nil); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	var account models.Account
+	if err := db.First(&account, "id = ?", "account1").Error; err != nil || account.Platform != "x" || account.ReportCount != 1 {
+		t.Errorf("Expected the account to be kept as an X account, got %+v (%v)", account, err)
+	}
+
+	var countries []models.AccountCountry
+	db.Find(&countries)
+	if len(countries) != 1 || countries[0].Platform != "x" || countries[0].CountryCode != "DE" {
+		t.Errorf("Expected the country to be kept for the X account, got %v", countries)
+	}
+
+	// The same ID on another platform is a different account
+	other := models.Account{Platform: "telegram", ID: "account1", Name: "Other", Countries: []string{"FR"}}
+	if err := db.Create(&other).Error; err != nil {
+		t.Fatalf("Failed to create account on another platform: %v", err)
+	}
+
+	var count int64
+	db.Model(&models.Account{}).Where("id = ?", "account1").Count(&count)
+	if count != 2 {
+		t.Errorf("Expected 2 accounts with the same ID, got %d", count)
+	}
+	db.Model(&models.AccountCountry{}).Where("account_id = ?", "account1").Count(&count)
+	if count != 2 {
+		t.Errorf("Expected each account to keep its own countries, got %d rows", count)
+	}
+}
+
+func TestMigrateDropsClientSecrets(t *testing.T) {
+	db := newLegacyTestDB(t)
+
+	// Simulate a database storing shared client secrets in plaintext
+	if err := db.Exec(`CREATE TABLE clients (id text PRIMARY KEY, secret text, registered_at datetime)`).Error; err != nil {
+		t.Fatalf("Failed to create legacy table: %v", err)
+	}
+	if err := db.Exec(`INSERT INTO clients VALUES ('client1', 'plaintext', '2025-02-20 12:00:00+00:00')`).Error; err != nil {
+		t.Fatalf("Failed to insert legacy client: %v", err)
+	}
+
+	if err := Migrate(db, nil); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	if db.Migrator().HasColumn("clients", "secret") {
+		t.Errorf("Expected the secret column to be dropped")
+	}
+
+	var count int64
+	db.Model(&models.Client{}).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected clients without a public key to be removed, got %d", count)
+	}
+}
+
+func TestMigrateBackfillsPostCountries(t *testing.T) {
+	db := newLegacyTestDB(t)
+
+	// Simulate a database created before the post country join table
+	// existed
+	if err := db.AutoMigrate(&models.WithheldPost{}); err != nil {
+		t.Fatalf("Failed to create posts table: %v", err)
+	}
+	if err := db.Exec(`INSERT INTO withheld_posts (platform, id, author_id, countries, report_count)
+		VALUES ('x', 'post1', 'author1', '["DE","FR"]', 1)`).Error; err != nil {
+		t.Fatalf("Failed to insert legacy post: %v", err)
+	}
+
+	if err := Migrate(db, nil); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	var countries []models.PostCountry
+	db.Order("country_code").Find(&countries)
+	if len(countries) != 2 || countries[0].CountryCode != "DE" || countries[1].CountryCode != "FR" || countries[0].PostID != "post1" {
+		t.Errorf("Expected backfilled countries DE and FR, got %v", countries)
+	}
+}
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/takedown-observer/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// platformKeyedTables are keyed by account platform and ID
+var platformKeyedTables = []interface{}{
+	&models.Account{},
+	&models.AccountCountry{},
+	&models.AccountName{},
+	&models.AccountReporter{},
+	&models.CountryConfirmation{},
+	&models.PurgedConfirmation{},
+}
+
+// migratePlatformKeys rebuilds the tables keyed by account ID alone, which
+// earlier versions created for X accounts only, so that they are keyed by
+// platform and ID. SQLite cannot change the primary key of a table, so each
+// table is recreated and its rows copied. Accounts keep their rowids, which
+// the search index refers to. Search triggers are recreated by
+// migrateSearchIndex.
+func migratePlatformKeys(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		schemas := make([]*schema.Schema, len(platformKeyedTables))
+		tables := make([]string, len(platformKeyedTables))
+		for i, model := range platformKeyedTables {
+			stmt := &gorm.Statement{DB: tx}
+			if err := stmt.Parse(model); err != nil {
+				return err
+			}
+			schemas[i], tables[i] = stmt.Schema, stmt.Schema.Table
+		}
+
+		// Triggers are dropped up front, since renaming a table rewrites
+		// the triggers of other tables that refer to it
+		var triggers []string
+		err := tx.Raw("SELECT name FROM sqlite_master WHERE type = 'trigger' AND tbl_name IN ?", tables).
+			Scan(&triggers).Error
+		if err != nil {
+			return err
+		}
+		for _, trigger := range triggers {
+			if err := tx.Exec(fmt.Sprintf("DROP TRIGGER %q", trigger)).Error; err != nil {
+				return err
+			}
+		}
+
+		for i, model := range platformKeyedTables {
+			table := tables[i]
+			legacy := table + "_legacy"
+
+			if err := tx.Exec(fmt.Sprintf("ALTER TABLE %q RENAME TO %q", table, legacy)).Error; err != nil {
+				return err
+			}
+
+			// Indexes keep their names when their table is renamed, so
+			// they are dropped before the new table creates them again
+			var indexes []string
+			err := tx.Raw("SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = ? AND sql IS NOT NULL", legacy).
+				Scan(&indexes).Error
+			if err != nil {
+				return err
+			}
+			for _, index := range indexes {
+				if err := tx.Exec(fmt.Sprintf("DROP INDEX %q", index)).Error; err != nil {
+					return err
+				}
+			}
+
+			if err := tx.Migrator().CreateTable(model); err != nil {
+				return err
+			}
+
+			columnTypes, err := tx.Migrator().ColumnTypes(legacy)
+			if err != nil {
+				return err
+			}
+			columns := []string{"rowid"}
+			for _, columnType := range columnTypes {
+				if schemas[i].LookUpField(columnType.Name()) != nil {
+					columns = append(columns, fmt.Sprintf("%q", columnType.Name()))
+				}
+			}
+			columnList := strings.Join(columns, ", ")
+
+			err = tx.Exec(fmt.Sprintf("INSERT INTO %q (%s) SELECT %s FROM %q", table, columnList, columnList, legacy)).Error
+			if err != nil {
+				return err
+			}
+
+			if err := tx.Exec(fmt.Sprintf("DROP TABLE %q", legacy)).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
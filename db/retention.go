@@ -0,0 +1,44 @@
+package db
+
+import (
+	"time"
+
+	"github.com/takedown-observer/backend/models"
+	"gorm.io/gorm"
+)
+
+// PurgeReporters removes links between clients and the accounts and posts
+// they reported that were last refreshed before the given time. Report
+// counts are kept, and purged confirmations are added to the per-country
+// totals. A client reporting an account or post again after its link was
+// purged is counted as a new reporter.
+func PurgeReporters(db *gorm.DB, before time.Time) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("last_reported_at < ?", before).Delete(&models.AccountReporter{}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("last_reported_at < ?", before).Delete(&models.PostReporter{}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Exec(`INSERT INTO purged_confirmations (platform, account_id, country_code, count)
+			SELECT platform, account_id, country_code, COUNT(*) FROM country_confirmations
+			WHERE last_confirmed_at < ? GROUP BY platform, account_id, country_code
+			ON CONFLICT (platform, account_id, country_code) DO UPDATE SET count = count + excluded.count`, before).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("last_confirmed_at < ?", before).Delete(&models.CountryConfirmation{}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Observation{}).
+			Where("observed_at < ? AND client_hash <> ''", before).
+			Update("client_hash", "").Error
+	})
+}
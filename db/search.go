@@ -0,0 +1,70 @@
+package db
+
+import (
+	"log"
+	"strings"
+
+	"github.com/takedown-observer/backend/models"
+	"gorm.io/gorm"
+)
+
+// searchIndexStatements create and populate the FTS5 account search table.
+// Search rows share the rowid of the account they index. The names column
+// holds every name the account has been observed under, space separated.
+var searchIndexStatements = []string{
+	`CREATE VIRTUAL TABLE ` + models.AccountSearchTable + ` USING fts5(account_id, name, names, tokenize = 'trigram')`,
+	`INSERT INTO account_search (rowid, account_id, name, names)
+		SELECT rowid, id, name, COALESCE(
+			(SELECT group_concat(name, ' ') FROM account_names
+				WHERE account_names.platform = accounts.platform AND account_id = accounts.id),
+			name)
+		FROM accounts`,
+}
+
+// searchTriggerStatements keep the search table in line with the accounts
+// and account_names tables
+var searchTriggerStatements = []string{
+	// Superseded by account_search_account_name
+	`DROP TRIGGER IF EXISTS account_search_observation`,
+	`DROP TRIGGER IF EXISTS account_search_name`,
+	`CREATE TRIGGER IF NOT EXISTS account_search_insert AFTER INSERT ON accounts BEGIN
+		INSERT INTO account_search (rowid, account_id, name, names) VALUES (new.rowid, new.id, new.name, new.name);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS account_search_update AFTER UPDATE OF name ON accounts BEGIN
+		UPDATE account_search SET name = new.name WHERE rowid = new.rowid;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS account_search_delete AFTER DELETE ON accounts BEGIN
+		DELETE FROM account_search WHERE rowid = old.rowid;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS account_search_account_name AFTER INSERT ON account_names BEGIN
+		UPDATE account_search SET names = names || ' ' || new.name
+		WHERE rowid = (SELECT rowid FROM accounts WHERE platform = new.platform AND id = new.account_id)
+			AND instr(' ' || names || ' ', ' ' || new.name || ' ') = 0;
+	END`,
+}
+
+// migrateSearchIndex creates the account search index if it does not exist
+// and brings its triggers up to date. SQLite builds without FTS5 leave
+// search to fall back to LIKE matching.
+func migrateSearchIndex(db *gorm.DB) error {
+	var statements []string
+	if !db.Migrator().HasTable(models.AccountSearchTable) {
+		statements = append(statements, searchIndexStatements...)
+	}
+	statements = append(statements, searchTriggerStatements...)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err != nil && strings.Contains(err.Error(), "no such module: fts5") {
+		log.Printf("SQLite was built without FTS5 (build with -tags sqlite_fts5); account search will use LIKE matching")
+		return nil
+	}
+	return err
+}
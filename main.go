@@ -1,14 +1,19 @@
 package main
 
 import (
+	"expvar"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
+	"time"
 
 	"github.com/takedown-observer/backend/api"
 	"github.com/takedown-observer/backend/db"
 	"github.com/takedown-observer/backend/router"
+	"github.com/takedown-observer/backend/validation"
+	"gorm.io/gorm"
 )
 
 func main() {
@@ -24,18 +29,70 @@ func main() {
 		log.Fatalf("Failed to create database directory: %v", err)
 	}
 
+	// Client IDs are stored as hashes keyed with this secret. Without it,
+	// anyone with a copy of the database could link clients to their reports.
+	clientHashKey := []byte(os.Getenv("CLIENT_HASH_SECRET"))
+	if len(clientHashKey) == 0 {
+		log.Fatal("CLIENT_HASH_SECRET must be set")
+	}
+
 	// Initialize database
 	log.Printf("Initializing database at: %s", dbPath)
-	database, err := db.New(dbPath)
+	database, err := db.New(dbPath, clientHashKey)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	// Purge reporter links older than the retention window, if set
+	retentionDays := 0
+	envInt("REPORTER_RETENTION_DAYS", 0, &retentionDays)
+	if retentionDays > 0 {
+		go purgeReporters(database, time.Duration(retentionDays)*24*time.Hour)
+	}
+
+	// Load handler configuration from environment
+	config := api.DefaultConfig()
+	config.ClientHashKey = clientHashKey
+	envInt("MAX_BATCH_SIZE", 1, &config.MaxBatchSize)
+	if mode := os.Getenv("SIGNATURE_MODE"); mode != "" {
+		if err := validation.ValidateOneOf("SIGNATURE_MODE", mode, api.SignatureModeOff, api.SignatureModeLog, api.SignatureModeEnforce); err != nil {
+			log.Fatal(err)
+		}
+		config.SignatureMode = mode
+	}
+	if requirePoW := os.Getenv("REQUIRE_PROOF_OF_WORK"); requirePoW != "" {
+		required, err := strconv.ParseBool(requirePoW)
+		if err != nil {
+			log.Fatalf("Invalid REQUIRE_PROOF_OF_WORK: %q", requirePoW)
+		}
+		config.RequireProofOfWork = required
+	}
+	config.ChallengeSecret = []byte(os.Getenv("CHALLENGE_SECRET"))
+	envInt("MIN_CHALLENGE_DIFFICULTY", 0, &config.MinChallengeDifficulty)
+	envInt("MAX_CHALLENGE_DIFFICULTY", config.MinChallengeDifficulty, &config.MaxChallengeDifficulty)
+
 	// Create API handler
-	handler := api.NewHandler(database)
+	handler := api.NewHandlerWithConfig(database, config)
+
+	// Load router configuration from environment
+	routerConfig := router.DefaultConfig()
+	envInt("RATE_LIMIT_CLIENT_PER_MINUTE", 0, &routerConfig.RateLimit.ClientPerMinute)
+	envInt("RATE_LIMIT_CLIENT_BURST", 1, &routerConfig.RateLimit.ClientBurst)
+	envInt("RATE_LIMIT_IP_PER_MINUTE", 0, &routerConfig.RateLimit.IPPerMinute)
+	envInt("RATE_LIMIT_IP_BURST", 1, &routerConfig.RateLimit.IPBurst)
+	routerConfig.RateLimit.TrustedProxyHeader = os.Getenv("TRUSTED_PROXY_HEADER")
+	if err := routerConfig.RateLimit.Validate(config.MaxBatchSize); err != nil {
+		log.Fatalf("Invalid rate limit configuration: %v", err)
+	}
 
 	// Set up router
-	r := router.New(handler)
+	r := router.NewWithConfig(handler, routerConfig)
+
+	// Serve metrics, including rate limit rejections, on a separate
+	// listener that should not be reachable from the public network
+	if metricsAddr := os.Getenv("METRICS_ADDR"); metricsAddr != "" {
+		go serveMetrics(metricsAddr)
+	}
 
 	// Start server
 	log.Printf("Server starting on :80...")
@@ -43,3 +100,42 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+// envInt overrides value with the integer environment variable name, if set.
+// Values below min are rejected.
+func envInt(name string, min int, value *int) {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return
+	}
+
+	parsed, err := strconv.Atoi(raw)
+	if err != nil || parsed < min {
+		log.Fatalf("Invalid %s: %q", name, raw)
+	}
+	*value = parsed
+}
+
+// serveMetrics serves expvar metrics at /debug/vars on addr
+func serveMetrics(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+
+	log.Printf("Metrics listening on %s...", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// purgeReporters periodically removes reporter links older than retention
+func purgeReporters(database *gorm.DB, retention time.Duration) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		if err := db.PurgeReporters(database, time.Now().UTC().Add(-retention)); err != nil {
+			log.Printf("Error purging reporters: %v", err)
+		}
+		<-ticker.C
+	}
+}
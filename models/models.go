@@ -2,26 +2,267 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// DataFormatVersion is the current version of the report payload format.
+// Reports in older supported versions are upgraded to it when decoded.
 const DataFormatVersion = "1.0"
 
-// Account represents a reported account in the database
+// AccountSearchTable is the FTS5 table indexing account IDs and names. It
+// only exists when SQLite is built with FTS5 support.
+const AccountSearchTable = "account_search"
+
+// Country event types
+const (
+	EventImposed = "imposed"
+	EventLifted  = "lifted"
+)
+
+// Account represents a reported account in the database. Accounts are
+// keyed by platform and ID, since IDs are only unique within a platform. The
+// reason and notice are those of the latest report.
 type Account struct {
-	ID                string    `gorm:"primarykey" json:"id"`
+	Platform          string              `gorm:"primarykey;default:x" json:"platform"`
+	ID                string              `gorm:"primarykey" json:"id"`
+	Name              string              `json:"name"`
+	Countries         []string            `gorm:"serializer:json" json:"countries"`
+	FirstSeenAt       time.Time           `gorm:"index" json:"first_seen_at"`
+	LastReportedAt    time.Time           `gorm:"index" json:"last_reported_at"`
+	ReportCount       int                 `json:"report_count"`
+	DataFormatVersion string              `json:"data_format_version"`
+	Reason            string              `gorm:"index;default:unspecified" json:"reason"`
+	Notice            string              `json:"notice,omitempty"`
+	PreviousNames     []string            `gorm:"-" json:"previous_names,omitempty"`
+	CountryConfidence []CountryConfidence `gorm:"-" json:"country_confidence,omitempty"`
+	Score             float64             `gorm:"->;-:migration" json:"score,omitempty"`
+}
+
+// AfterSave keeps the country join table in line with the countries the
+// account was most recently reported withheld in, and records its name in
+// the account's name history
+func (a *Account) AfterSave(tx *gorm.DB) error {
+	tx = tx.Session(&gorm.Session{NewDB: true})
+
+	if err := a.syncCountries(tx); err != nil {
+		return err
+	}
+	return a.recordName(tx)
+}
+
+// syncCountries replaces the account's rows in the country join table
+func (a *Account) syncCountries(tx *gorm.DB) error {
+	stale := tx.Where("platform = ? AND account_id = ?", a.Platform, a.ID)
+	if len(a.Countries) > 0 {
+		stale = stale.Where("country_code NOT IN ?", a.Countries)
+	}
+	if err := stale.Delete(&AccountCountry{}).Error; err != nil || len(a.Countries) == 0 {
+		return err
+	}
+
+	rows := make([]AccountCountry, len(a.Countries))
+	for i, country := range a.Countries {
+		rows[i] = AccountCountry{
+			Platform:    a.Platform,
+			AccountID:   a.ID,
+			CountryCode: country,
+			FirstSeenAt: a.LastReportedAt,
+			LastSeenAt:  a.LastReportedAt,
+		}
+	}
+
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "platform"}, {Name: "account_id"}, {Name: "country_code"}},
+		DoUpdates: clause.AssignmentColumns([]string{"last_seen_at"}),
+	}).Create(&rows).Error
+}
+
+// recordName adds the account's current name to its name history
+func (a *Account) recordName(tx *gorm.DB) error {
+	if a.Name == "" {
+		return nil
+	}
+
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "platform"}, {Name: "account_id"}, {Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"last_seen_at"}),
+	}).Create(&AccountName{
+		Platform:    a.Platform,
+		AccountID:   a.ID,
+		Name:        a.Name,
+		FirstSeenAt: a.LastReportedAt,
+		LastSeenAt:  a.LastReportedAt,
+	}).Error
+}
+
+// AccountName represents a name an account has been observed under
+type AccountName struct {
+	Platform    string    `gorm:"primaryKey;default:x" json:"-"`
+	AccountID   string    `gorm:"primaryKey" json:"-"`
+	Name        string    `gorm:"primaryKey" json:"name"`
+	FirstSeenAt time.Time `json:"first_seen_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+}
+
+// AccountCountry represents a country an account is currently withheld in.
+// It mirrors Account.Countries in normalized form so accounts can be
+// filtered by exact country code.
+type AccountCountry struct {
+	Platform    string    `gorm:"primaryKey;default:x;index:idx_account_countries_country,priority:2" json:"platform"`
+	AccountID   string    `gorm:"primaryKey;index:idx_account_countries_country,priority:3" json:"account_id"`
+	CountryCode string    `gorm:"primaryKey;index:idx_account_countries_country,priority:1" json:"country"`
+	FirstSeenAt time.Time `json:"first_seen_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+}
+
+// AccountReporter records that a client reported an account. Clients are
+// identified by keyed hashes, and links are purged once the reporter
+// retention window has passed.
+type AccountReporter struct {
+	Platform        string `gorm:"primaryKey;default:x"`
+	AccountID       string `gorm:"primaryKey"`
+	ClientHash      string `gorm:"primaryKey"`
+	FirstReportedAt time.Time
+	LastReportedAt  time.Time `gorm:"index"`
+}
+
+// CountryConfirmation records that a client observed an account withheld
+// in a country. Each client confirms a country at most once.
+type CountryConfirmation struct {
+	Platform        string    `gorm:"primaryKey;default:x;index:idx_country_confirmations_country,priority:2"`
+	AccountID       string    `gorm:"primaryKey;index:idx_country_confirmations_country,priority:3"`
+	CountryCode     string    `gorm:"primaryKey;index:idx_country_confirmations_country,priority:1"`
+	ClientHash      string    `gorm:"primaryKey"`
+	LastConfirmedAt time.Time `gorm:"index"`
+}
+
+// PurgedConfirmation counts the confirmations of a country that were purged
+// with their reporter links, so confirmation counts are kept
+type PurgedConfirmation struct {
+	Platform    string `gorm:"primaryKey;default:x"`
+	AccountID   string `gorm:"primaryKey"`
+	CountryCode string `gorm:"primaryKey"`
+	Count       int
+}
+
+// CountryConfidence represents how many independent clients observed an
+// account withheld in a country. Confidence is the share of the account's
+// reporters that did.
+type CountryConfidence struct {
+	Country    string  `json:"country"`
+	Reporters  int     `json:"reporters"`
+	Confidence float64 `json:"confidence"`
+}
+
+// Observation represents a single report of an account's withholding status
+type Observation struct {
+	ID                uint      `gorm:"primarykey" json:"-"`
+	Platform          string    `gorm:"default:x" json:"platform"`
+	AccountID         string    `gorm:"index:idx_observations_account_observed,priority:1" json:"account_id"`
+	ClientHash        string    `json:"-"`
 	Name              string    `json:"name"`
 	Countries         []string  `gorm:"serializer:json" json:"countries"`
-	LastReportedAt    time.Time `json:"last_reported_at"`
+	ObservedAt        time.Time `gorm:"index:idx_observations_account_observed,priority:2" json:"observed_at"`
+	DataFormatVersion string    `json:"data_format_version"`
+	Reason            string    `gorm:"default:unspecified" json:"reason"`
+	Notice            string    `json:"notice,omitempty"`
+}
+
+// CountryEvent represents a change in the set of countries an account is
+// withheld in, detected by comparing a report with the stored countries
+type CountryEvent struct {
+	ID          uint       `gorm:"primarykey" json:"id"`
+	Platform    string     `gorm:"default:x" json:"platform"`
+	AccountID   string     `gorm:"index" json:"account_id"`
+	CountryCode string     `gorm:"index:idx_country_events_country_type,priority:1" json:"country"`
+	Type        string     `gorm:"index:idx_country_events_country_type,priority:2" json:"type"`
+	Reason      string     `gorm:"default:unspecified" json:"reason"`
+	OccurredAt  time.Time  `gorm:"index" json:"occurred_at"`
+	ImposedAt   *time.Time `json:"imposed_at,omitempty"`
+}
+
+// Client represents a registered reporting client. Reports are signed with
+// the client's Ed25519 private key, so only the public key is stored and a
+// copy of the database cannot be used to forge signatures.
+type Client struct {
+	ID           string    `gorm:"primarykey" json:"client_id"`
+	PublicKey    string    `json:"-"`
+	RegisteredAt time.Time `json:"registered_at"`
+}
+
+// WithheldPost represents a reported post that is withheld in some
+// countries. Posts are keyed by platform and post ID, and refer to the
+// account that authored them, which need not have been reported itself. Post
+// text is not stored; clients report a hash of it so that edits can be told
+// apart.
+type WithheldPost struct {
+	Platform          string    `gorm:"primarykey;default:x;index:idx_withheld_posts_author,priority:1" json:"platform"`
+	ID                string    `gorm:"primarykey" json:"id"`
+	AuthorID          string    `gorm:"index:idx_withheld_posts_author,priority:2" json:"author_id"`
+	Countries         []string  `gorm:"serializer:json" json:"countries"`
+	TextHash          string    `json:"text_hash,omitempty"`
+	URL               string    `json:"url,omitempty"`
+	FirstSeenAt       time.Time `gorm:"index" json:"first_seen_at"`
+	LastReportedAt    time.Time `gorm:"index" json:"last_reported_at"`
 	ReportCount       int       `json:"report_count"`
-	ReportedBy        []string  `gorm:"serializer:json" json:"-"`
 	DataFormatVersion string    `json:"data_format_version"`
+	AuthorURL         string    `gorm:"-" json:"author_url"`
 }
 
-// ReportedAccount represents the account data in a report request
+// AfterSave keeps the country join table in line with the countries the post
+// was most recently reported withheld in
+func (p *WithheldPost) AfterSave(tx *gorm.DB) error {
+	return p.syncCountries(tx.Session(&gorm.Session{NewDB: true}))
+}
+
+// syncCountries replaces the post's rows in the country join table
+func (p *WithheldPost) syncCountries(tx *gorm.DB) error {
+	stale := tx.Where("platform = ? AND post_id = ?", p.Platform, p.ID)
+	if len(p.Countries) > 0 {
+		stale = stale.Where("country_code NOT IN ?", p.Countries)
+	}
+	if err := stale.Delete(&PostCountry{}).Error; err != nil || len(p.Countries) == 0 {
+		return err
+	}
+
+	rows := make([]PostCountry, len(p.Countries))
+	for i, country := range p.Countries {
+		rows[i] = PostCountry{Platform: p.Platform, PostID: p.ID, CountryCode: country}
+	}
+
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+}
+
+// PostCountry represents a country a post is currently withheld in. Like
+// AccountCountry, it mirrors WithheldPost.Countries in normalized form so
+// posts can be filtered by exact country code.
+type PostCountry struct {
+	Platform    string `gorm:"primaryKey;default:x;index:idx_post_countries_country,priority:2" json:"platform"`
+	PostID      string `gorm:"primaryKey;index:idx_post_countries_country,priority:3" json:"post_id"`
+	CountryCode string `gorm:"primaryKey;index:idx_post_countries_country,priority:1" json:"country"`
+}
+
+// PostReporter records that a client reported a post, like AccountReporter
+// does for accounts
+type PostReporter struct {
+	Platform        string `gorm:"primaryKey;default:x"`
+	PostID          string `gorm:"primaryKey"`
+	ClientHash      string `gorm:"primaryKey"`
+	FirstReportedAt time.Time
+	LastReportedAt  time.Time `gorm:"index"`
+}
+
+// ReportedAccount represents the account data in a report request. The
+// reason and notice describe the withholding notice shown on the account.
 type ReportedAccount struct {
+	Platform  string   `json:"platform,omitempty"`
 	ID        string   `json:"id"`
 	Name      string   `json:"name"`
 	Countries []string `json:"countries"`
+	Reason    string   `json:"reason,omitempty"`
+	Notice    string   `json:"notice,omitempty"`
 }
 
 // ReportRequest represents the full report request from a client
@@ -31,11 +272,238 @@ type ReportRequest struct {
 	DataFormatVersion string          `json:"data_format_version"`
 }
 
-// AccountsResponse represents the response for the accounts listing endpoint
+// ReportedPost represents the post data in a post report request. The text
+// hash is the hex-encoded SHA-256 hash of the post text as displayed.
+type ReportedPost struct {
+	Platform  string   `json:"platform,omitempty"`
+	ID        string   `json:"id"`
+	AuthorID  string   `json:"author_id"`
+	Countries []string `json:"countries"`
+	TextHash  string   `json:"text_hash,omitempty"`
+	URL       string   `json:"url,omitempty"`
+}
+
+// PostReportRequest represents the full post report request from a client
+type PostReportRequest struct {
+	ClientID          string       `json:"client_id"`
+	Post              ReportedPost `json:"post"`
+	DataFormatVersion string       `json:"data_format_version"`
+}
+
+// Problem represents an error response as RFC 7807 problem details. Code is
+// a machine-readable error code, and Field names the request field or query
+// parameter at fault, if any.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail"`
+	Instance  string `json:"instance,omitempty"`
+	Code      string `json:"code"`
+	Field     string `json:"field,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// DataFormat describes a supported version of the report payload format.
+// Deprecated versions are still accepted until their sunset.
+type DataFormat struct {
+	Version      string     `json:"version"`
+	Current      bool       `json:"current"`
+	DeprecatedAt *time.Time `json:"deprecated_at,omitempty"`
+	SunsetAt     *time.Time `json:"sunset_at,omitempty"`
+}
+
+// FormatsResponse represents the response for the data formats endpoint
+type FormatsResponse struct {
+	Current string       `json:"current"`
+	Formats []DataFormat `json:"formats"`
+}
+
+// ClientRegistrationRequest represents a client registration request. The
+// public key is a base64url-encoded Ed25519 key without padding.
+type ClientRegistrationRequest struct {
+	PublicKey string `json:"public_key"`
+}
+
+// ClientRegistration represents the response for the client registration
+// endpoint
+type ClientRegistration struct {
+	ClientID string `json:"client_id"`
+}
+
+// Challenge represents a proof-of-work challenge issued to a client
+type Challenge struct {
+	Challenge  string    `json:"challenge"`
+	Difficulty int       `json:"difficulty"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// Batch item statuses
+const (
+	BatchStatusSuccess = "success"
+	BatchStatusError   = "error"
+)
+
+// BatchItemResult represents the outcome of a single report in a batch.
+// Failed reports carry the code and field of their error as in a Problem.
+type BatchItemResult struct {
+	Index     int    `json:"index"`
+	AccountID string `json:"account_id"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	Code      string `json:"code,omitempty"`
+	Field     string `json:"field,omitempty"`
+}
+
+// BatchReportResponse represents the response for the batch report endpoint
+type BatchReportResponse struct {
+	Results []BatchItemResult `json:"results"`
+}
+
+// AccountExport represents an account in typed dataset exports
+type AccountExport struct {
+	Platform          string    `json:"platform" parquet:"platform"`
+	ID                string    `json:"id" parquet:"id"`
+	Name              string    `json:"name" parquet:"name"`
+	Countries         []string  `json:"countries" parquet:"countries,list"`
+	ReportCount       int64     `json:"report_count" parquet:"report_count"`
+	FirstSeenAt       time.Time `json:"first_seen_at" parquet:"first_seen_at,timestamp(millisecond:utc)"`
+	LastReportedAt    time.Time `json:"last_reported_at" parquet:"last_reported_at,timestamp(millisecond:utc)"`
+	DataFormatVersion string    `json:"data_format_version" parquet:"data_format_version"`
+}
+
+// PostExport represents a withheld post in typed dataset exports
+type PostExport struct {
+	Platform          string    `json:"platform" parquet:"platform"`
+	ID                string    `json:"id" parquet:"id"`
+	AuthorID          string    `json:"author_id" parquet:"author_id"`
+	Countries         []string  `json:"countries" parquet:"countries,list"`
+	TextHash          string    `json:"text_hash" parquet:"text_hash"`
+	URL               string    `json:"url" parquet:"url"`
+	ReportCount       int64     `json:"report_count" parquet:"report_count"`
+	FirstSeenAt       time.Time `json:"first_seen_at" parquet:"first_seen_at,timestamp(millisecond:utc)"`
+	LastReportedAt    time.Time `json:"last_reported_at" parquet:"last_reported_at,timestamp(millisecond:utc)"`
+	DataFormatVersion string    `json:"data_format_version" parquet:"data_format_version"`
+}
+
+// AccountsResponse represents the response for the accounts listing
+// endpoint. Reasons counts the matching accounts by withholding reason,
+// disregarding any reason filter.
 type AccountsResponse struct {
-	Accounts        []Account `json:"accounts"`
-	TotalCount      int64     `json:"totalCount"`
-	CurrentPage     int       `json:"currentPage"`
-	TotalPages      int       `json:"totalPages"`
-	UniqueCountries []string  `json:"uniqueCountries"`
+	Accounts        []Account     `json:"accounts"`
+	TotalCount      int64         `json:"totalCount"`
+	CurrentPage     int           `json:"currentPage"`
+	TotalPages      int           `json:"totalPages"`
+	UniqueCountries []string      `json:"uniqueCountries"`
+	Reasons         []ReasonCount `json:"reasons"`
+	NextCursor      string        `json:"nextCursor,omitempty"`
+}
+
+// ReasonCount represents the number of accounts or events with a
+// withholding reason
+type ReasonCount struct {
+	Reason string `json:"reason"`
+	Count  int64  `json:"count"`
+}
+
+// PostsResponse represents the response for the withheld posts listing
+// endpoint
+type PostsResponse struct {
+	Posts       []WithheldPost `json:"posts"`
+	TotalCount  int64          `json:"totalCount"`
+	CurrentPage int            `json:"currentPage"`
+	TotalPages  int            `json:"totalPages"`
+	NextCursor  string         `json:"nextCursor,omitempty"`
+}
+
+// HistoryEntry represents a period during which an account was observed
+// withheld in the same set of countries
+type HistoryEntry struct {
+	Countries        []string  `json:"countries"`
+	FirstObservedAt  time.Time `json:"first_observed_at"`
+	LastObservedAt   time.Time `json:"last_observed_at"`
+	ObservationCount int       `json:"observation_count"`
+}
+
+// HistoryResponse represents the response for the account history endpoint
+type HistoryResponse struct {
+	Platform  string         `json:"platform"`
+	AccountID string         `json:"account_id"`
+	History   []HistoryEntry `json:"history"`
+}
+
+// AccountCountryDetail represents when an account was observed withheld in
+// a country
+type AccountCountryDetail struct {
+	Country         string    `json:"country"`
+	FirstObservedAt time.Time `json:"first_observed_at"`
+	LastObservedAt  time.Time `json:"last_observed_at"`
+	Withheld        bool      `json:"withheld"`
+	Reporters       int       `json:"reporters"`
+	Confidence      float64   `json:"confidence"`
+}
+
+// AccountDetail represents the response for the account detail endpoint
+type AccountDetail struct {
+	Account
+	ReporterCount int                    `json:"reporter_count"`
+	CountryDetail []AccountCountryDetail `json:"country_detail"`
+	NameHistory   []AccountName          `json:"name_history"`
+}
+
+// EventsResponse represents the response for the country events endpoint
+type EventsResponse struct {
+	Events     []CountryEvent `json:"events"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// CountryStats represents aggregate withholding statistics for a country.
+// TotalReports counts the reports listing the country, including repeated
+// reports by the same client. Reasons counts the country's accounts by their
+// latest withholding reason.
+type CountryStats struct {
+	Country         string        `json:"country"`
+	Accounts        int64         `json:"accounts"`
+	TotalReports    int64         `json:"total_reports"`
+	FirstObservedAt time.Time     `json:"first_observed_at"`
+	LastObservedAt  time.Time     `json:"last_observed_at"`
+	NewLast7Days    int64         `json:"new_last_7_days"`
+	NewLast30Days   int64         `json:"new_last_30_days"`
+	Reasons         []ReasonCount `json:"reasons"`
+}
+
+// CountryStatsResponse represents the response for the country statistics
+// endpoint
+type CountryStatsResponse struct {
+	Countries []CountryStats `json:"countries"`
+}
+
+// TimeSeriesPoint represents the number of country events in one interval
+type TimeSeriesPoint struct {
+	Start   time.Time `json:"start"`
+	Imposed int64     `json:"imposed"`
+	Lifted  *int64    `json:"lifted,omitempty"`
+}
+
+// TimeSeriesResponse represents the response for the time series endpoint.
+// Reasons counts the imposed events in the range by withholding reason.
+type TimeSeriesResponse struct {
+	Country  string            `json:"country,omitempty"`
+	Reason   string            `json:"reason,omitempty"`
+	Interval string            `json:"interval"`
+	From     time.Time         `json:"from"`
+	To       time.Time         `json:"to"`
+	Points   []TimeSeriesPoint `json:"points"`
+	Reasons  []ReasonCount     `json:"reasons"`
+}
+
+// CountryCount represents the number of accounts withheld in a country
+type CountryCount struct {
+	Country  string `json:"country"`
+	Accounts int64  `json:"accounts"`
+}
+
+// CountriesResponse represents the response for the countries endpoint
+type CountriesResponse struct {
+	Countries []CountryCount `json:"countries"`
 }
@@ -10,7 +10,6 @@ func TestAccountJSONSerialization(t *testing.T) {
 		ID:                "test_account",
 		Name:              "TestAccount",
 		Countries:         []string{"US", "GB"},
-		ReportedBy:        []string{"client1"},
 		DataFormatVersion: "1.0",
 	}
 
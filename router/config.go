@@ -0,0 +1,50 @@
+package router
+
+import "fmt"
+
+// Config holds the tunable settings of the router
+type Config struct {
+	RateLimit RateLimitConfig
+}
+
+// RateLimitConfig holds the limits on submitted reports. Each report in a
+// batch counts separately, so bursts should allow for a full batch. A zero
+// rate disables the corresponding limit.
+type RateLimitConfig struct {
+	// ClientPerMinute and ClientBurst limit reports per client ID
+	ClientPerMinute int
+	ClientBurst     int
+
+	// IPPerMinute and IPBurst limit reports per remote IP address
+	IPPerMinute int
+	IPBurst     int
+
+	// TrustedProxyHeader names the header holding the client IP address
+	// when running behind a reverse proxy, such as X-Forwarded-For. It must
+	// only be set if the proxy overwrites or appends to the header.
+	TrustedProxyHeader string
+}
+
+// Validate checks that the bursts allow for a full batch of maxBatchSize
+// reports, which could otherwise never be accepted
+func (c RateLimitConfig) Validate(maxBatchSize int) error {
+	if c.ClientPerMinute > 0 && c.ClientBurst < maxBatchSize {
+		return fmt.Errorf("client burst %d is below the maximum batch size %d", c.ClientBurst, maxBatchSize)
+	}
+	if c.IPPerMinute > 0 && c.IPBurst < maxBatchSize {
+		return fmt.Errorf("IP burst %d is below the maximum batch size %d", c.IPBurst, maxBatchSize)
+	}
+	return nil
+}
+
+// DefaultConfig returns the configuration used by New
+func DefaultConfig() Config {
+	return Config{
+		RateLimit: RateLimitConfig{
+			ClientPerMinute: 60,
+			ClientBurst:     100,
+			IPPerMinute:     300,
+			IPBurst:         500,
+		},
+	}
+}
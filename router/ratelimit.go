@@ -0,0 +1,223 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"expvar"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/takedown-observer/backend/api"
+)
+
+// rateLimitRejections counts rejected requests by the limit they exceeded
+var rateLimitRejections = expvar.NewMap("rate_limit_rejections")
+
+// Rejection metric keys
+const (
+	limitClient = "client"
+	limitIP     = "ip"
+)
+
+// Idle buckets are refilled by the time they are swept, so dropping them
+// does not change any limit
+const bucketSweepInterval = 10 * time.Minute
+
+// bucket is a token bucket holding up to burst tokens
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// limiter holds token buckets for one kind of key, refilled at rate tokens
+// per second up to burst. A zero rate disables the limit.
+type limiter struct {
+	rate    float64
+	burst   float64
+	buckets map[string]*bucket
+}
+
+func newLimiter(perMinute, burst int) *limiter {
+	return &limiter{
+		rate:    float64(perMinute) / 60,
+		burst:   float64(burst),
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// refill returns the bucket for a key with its tokens brought up to date
+func (l *limiter) refill(key string, now time.Time) *bucket {
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	return b
+}
+
+// wait returns how long until the key's bucket holds cost tokens
+func (l *limiter) wait(key string, cost float64, now time.Time) time.Duration {
+	b := l.refill(key, now)
+	if b.tokens >= cost {
+		return 0
+	}
+	return time.Duration((cost - b.tokens) / l.rate * float64(time.Second))
+}
+
+// sweep drops buckets that have been idle long enough to be full again
+func (l *limiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// rateLimiter limits reports per client ID and per remote IP. Bodies are
+// read to count their reports, up to maxBodySize bytes.
+type rateLimiter struct {
+	mu          sync.Mutex
+	clients     *limiter
+	ips         *limiter
+	header      string
+	maxBodySize int64
+	lastSweep   time.Time
+	now         func() time.Time
+}
+
+func newRateLimiter(config RateLimitConfig, maxBodySize int64) *rateLimiter {
+	return &rateLimiter{
+		clients:     newLimiter(config.ClientPerMinute, config.ClientBurst),
+		ips:         newLimiter(config.IPPerMinute, config.IPBurst),
+		header:      config.TrustedProxyHeader,
+		maxBodySize: maxBodySize,
+		now:         time.Now,
+	}
+}
+
+// allow takes one token per report from each client's bucket and from the
+// IP's bucket. Either all tokens are taken or none are; otherwise the
+// exceeded limit and the time until the request would be allowed are
+// returned.
+func (rl *rateLimiter) allow(ip string, clients map[string]int, reports int) (string, time.Duration) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := rl.now()
+	if now.Sub(rl.lastSweep) >= bucketSweepInterval {
+		rl.clients.sweep(now)
+		rl.ips.sweep(now)
+		rl.lastSweep = now
+	}
+
+	var exceeded string
+	var retryAfter time.Duration
+
+	if rl.ips.rate > 0 {
+		if wait := rl.ips.wait(ip, float64(reports), now); wait > 0 {
+			exceeded, retryAfter = limitIP, wait
+		}
+	}
+
+	if rl.clients.rate > 0 {
+		for clientID, cost := range clients {
+			if wait := rl.clients.wait(clientID, float64(cost), now); wait > retryAfter {
+				exceeded, retryAfter = limitClient, wait
+			}
+		}
+	}
+
+	if exceeded != "" {
+		return exceeded, retryAfter
+	}
+
+	if rl.ips.rate > 0 {
+		rl.ips.buckets[ip].tokens -= float64(reports)
+	}
+	if rl.clients.rate > 0 {
+		for clientID, cost := range clients {
+			rl.clients.buckets[clientID].tokens -= float64(cost)
+		}
+	}
+
+	return "", 0
+}
+
+// clientIP returns the address a request originates from. The trusted proxy
+// header is only honored when configured; for lists such as X-Forwarded-For
+// the last entry is the one added by the proxy and cannot be spoofed.
+func (rl *rateLimiter) clientIP(r *http.Request) string {
+	if rl.header != "" {
+		if value := r.Header.Get(rl.header); value != "" {
+			entries := strings.Split(value, ",")
+			if ip := strings.TrimSpace(entries[len(entries)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// reportedClients returns the number of reports per client ID in a single or
+// batch report body, and the total number of reports. Bodies that cannot be
+// parsed count as one report from no client and are rejected by the handler.
+func reportedClients(body []byte) (map[string]int, int) {
+	type report struct {
+		ClientID string `json:"client_id"`
+	}
+
+	var reports []report
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &reports); err != nil {
+			return nil, 1
+		}
+	} else {
+		var single report
+		if err := json.Unmarshal(trimmed, &single); err != nil {
+			return nil, 1
+		}
+		reports = []report{single}
+	}
+
+	clients := make(map[string]int)
+	for _, report := range reports {
+		clients[report.ClientID]++
+	}
+	return clients, max(len(reports), 1)
+}
+
+// middleware rejects report requests exceeding the configured limits with
+// 429 Too Many Requests
+func (rl *rateLimiter) middleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, ok := api.ReadBody(w, r, rl.maxBodySize)
+		if !ok {
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		clients, reports := reportedClients(body)
+		exceeded, retryAfter := rl.allow(rl.clientIP(r), clients, reports)
+		if exceeded != "" {
+			rateLimitRejections.Add(exceeded, 1)
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			api.WriteProblem(w, r, http.StatusTooManyRequests, "rate_limited", "", "Rate limit exceeded")
+			return
+		}
+
+		next(w, r)
+	}
+}
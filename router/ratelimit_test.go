@@ -0,0 +1,257 @@
+package router
+
+import (
+	"bytes"
+	"expvar"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/takedown-observer/backend/api"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		remoteAddr string
+		headers    map[string]string
+		expected   string
+	}{
+		{
+			name:       "remote address",
+			remoteAddr: "192.0.2.1:1234",
+			expected:   "192.0.2.1",
+		},
+		{
+			name:       "untrusted forwarded header",
+			remoteAddr: "192.0.2.1:1234",
+			headers:    map[string]string{"X-Forwarded-For": "198.51.100.1"},
+			expected:   "192.0.2.1",
+		},
+		{
+			name:       "trusted forwarded header uses proxy entry",
+			header:     "X-Forwarded-For",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.9, 198.51.100.1"},
+			expected:   "198.51.100.1",
+		},
+		{
+			name:       "missing trusted header",
+			header:     "X-Real-IP",
+			remoteAddr: "10.0.0.1:1234",
+			expected:   "10.0.0.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rl := newRateLimiter(RateLimitConfig{TrustedProxyHeader: tt.header}, api.MaxReportSize)
+
+			req := httptest.NewRequest("POST", "/api/report", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+
+			if got := rl.clientIP(req); got != tt.expected {
+				t.Errorf("clientIP() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReportedClients(t *testing.T) {
+	tests := []struct {
+		name            string
+		body            string
+		expectedClients map[string]int
+		expectedReports int
+	}{
+		{
+			name:            "single report",
+			body:            `{"client_id": "a"}`,
+			expectedClients: map[string]int{"a": 1},
+			expectedReports: 1,
+		},
+		{
+			name:            "batch",
+			body:            ` [{"client_id": "a"}, {"client_id": "b"}, {"client_id": "a"}]`,
+			expectedClients: map[string]int{"a": 2, "b": 1},
+			expectedReports: 3,
+		},
+		{
+			name:            "invalid body",
+			body:            `not json`,
+			expectedReports: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clients, reports := reportedClients([]byte(tt.body))
+			if !reflect.DeepEqual(clients, tt.expectedClients) || reports != tt.expectedReports {
+				t.Errorf("reportedClients() = %v, %d, want %v, %d", clients, reports, tt.expectedClients, tt.expectedReports)
+			}
+		})
+	}
+}
+
+func TestRateLimiterAllow(t *testing.T) {
+	now := time.Date(2025, 2, 20, 12, 0, 0, 0, time.UTC)
+	rl := newRateLimiter(RateLimitConfig{
+		ClientPerMinute: 60,
+		ClientBurst:     2,
+		IPPerMinute:     120,
+		IPBurst:         3,
+	}, api.MaxReportSize)
+	rl.now = func() time.Time { return now }
+
+	allow := func(ip, clientID string, reports int) (string, time.Duration) {
+		return rl.allow(ip, map[string]int{clientID: reports}, reports)
+	}
+
+	if exceeded, _ := allow("ip1", "a", 2); exceeded != "" {
+		t.Fatalf("Expected burst to be allowed, got %s limit", exceeded)
+	}
+
+	exceeded, retryAfter := allow("ip1", "a", 1)
+	if exceeded != limitClient || retryAfter != time.Second {
+		t.Errorf("Expected client limit with 1s retry, got %q with %v", exceeded, retryAfter)
+	}
+
+	// A rejected request takes no tokens from the IP bucket
+	if exceeded, _ := allow("ip1", "b", 1); exceeded != "" {
+		t.Errorf("Expected another client to be allowed, got %s limit", exceeded)
+	}
+
+	exceeded, retryAfter = allow("ip1", "c", 1)
+	if exceeded != limitIP || retryAfter != 500*time.Millisecond {
+		t.Errorf("Expected IP limit with 500ms retry, got %q with %v", exceeded, retryAfter)
+	}
+
+	if exceeded, _ := allow("ip2", "c", 1); exceeded != "" {
+		t.Errorf("Expected another IP to be allowed, got %s limit", exceeded)
+	}
+
+	now = now.Add(time.Second)
+	if exceeded, _ := allow("ip1", "a", 1); exceeded != "" {
+		t.Errorf("Expected refilled buckets to allow a report, got %s limit", exceeded)
+	}
+
+	now = now.Add(bucketSweepInterval)
+	allow("ip3", "d", 1)
+	if len(rl.clients.buckets) != 1 || len(rl.ips.buckets) != 1 {
+		t.Errorf("Expected idle buckets to be swept, got %d client and %d IP buckets", len(rl.clients.buckets), len(rl.ips.buckets))
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	rl := newRateLimiter(RateLimitConfig{ClientPerMinute: 1, ClientBurst: 1}, api.MaxReportSize)
+
+	var received []string
+	handler := rl.middleware(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, string(body))
+	})
+
+	rejections := func() int64 {
+		if count, ok := rateLimitRejections.Get(limitClient).(*expvar.Int); ok {
+			return count.Value()
+		}
+		return 0
+	}
+	before := rejections()
+	body := `{"client_id": "123e4567-e89b-12d3-a456-426614174000"}`
+
+	for i, expectedCode := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		req := httptest.NewRequest("POST", "/api/report", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		if w.Code != expectedCode {
+			t.Errorf("Request %d status code = %v, want %v", i, w.Code, expectedCode)
+		}
+	}
+
+	if !reflect.DeepEqual(received, []string{body}) {
+		t.Errorf("Expected handler to receive the body once, got %v", received)
+	}
+
+	req := httptest.NewRequest("POST", "/api/report", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	handler(w, req)
+	if w.Header().Get("Retry-After") != "60" {
+		t.Errorf("Expected Retry-After 60, got %q", w.Header().Get("Retry-After"))
+	}
+	if w.Header().Get("Content-Type") != api.ProblemContentType || !bytes.Contains(w.Body.Bytes(), []byte(`"code":"rate_limited"`)) {
+		t.Errorf("Expected a rate_limited problem, got %s", w.Body.String())
+	}
+
+	if got := rejections() - before; got != 2 {
+		t.Errorf("Expected 2 client rejections to be counted, got %d", got)
+	}
+}
+
+func TestRateLimitMiddlewareBodySize(t *testing.T) {
+	rl := newRateLimiter(RateLimitConfig{}, 64)
+
+	called := false
+	handler := rl.middleware(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+
+	body := `{"client_id": "123e4567-e89b-12d3-a456-426614174000", "account": {}}`
+	req := httptest.NewRequest("POST", "/api/report", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status code = %v, want %v", w.Code, http.StatusRequestEntityTooLarge)
+	}
+	if !bytes.Contains(w.Body.Bytes(), []byte(`"code":"body_too_large"`)) {
+		t.Errorf("Expected a body_too_large problem, got %s", w.Body.String())
+	}
+	if called {
+		t.Error("Expected the handler not to be called")
+	}
+}
+
+func TestRateLimitConfigValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      RateLimitConfig
+		expectError bool
+	}{
+		{
+			name:   "default",
+			config: DefaultConfig().RateLimit,
+		},
+		{
+			name:   "disabled limits",
+			config: RateLimitConfig{ClientBurst: 1, IPBurst: 1},
+		},
+		{
+			name:        "client burst below batch size",
+			config:      RateLimitConfig{ClientPerMinute: 60, ClientBurst: 10, IPPerMinute: 60, IPBurst: 100},
+			expectError: true,
+		},
+		{
+			name:        "IP burst below batch size",
+			config:      RateLimitConfig{ClientPerMinute: 60, ClientBurst: 100, IPPerMinute: 60, IPBurst: 1},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate(100)
+			if (err != nil) != tt.expectError {
+				t.Errorf("Validate() error = %v, expectError %v", err, tt.expectError)
+			}
+		})
+	}
+}
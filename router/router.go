@@ -4,19 +4,40 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
 	"github.com/takedown-observer/backend/api"
 )
 
-// New creates and configures a new router
+// New creates and configures a new router using the default configuration
 func New(handler *api.Handler) http.Handler {
+	return NewWithConfig(handler, DefaultConfig())
+}
+
+// NewWithConfig creates and configures a new router using the given
+// configuration
+func NewWithConfig(handler *api.Handler, config Config) http.Handler {
 	router := mux.NewRouter()
+	limiter := newRateLimiter(config.RateLimit, handler.MaxBodySize())
 
 	// API endpoints
-	router.HandleFunc("/api/report", handler.ReportHandler).Methods("POST")
+	router.HandleFunc("/api/report", limiter.middleware(handler.ReportHandler)).Methods("POST")
+	router.HandleFunc("/api/report/post", limiter.middleware(handler.ReportPostHandler)).Methods("POST")
+	router.HandleFunc("/api/reports/batch", limiter.middleware(handler.BatchReportHandler)).Methods("POST")
+	router.HandleFunc("/api/challenge", handler.GetChallengeHandler).Methods("GET")
+	router.HandleFunc("/api/formats", handler.GetFormatsHandler).Methods("GET")
+	router.HandleFunc("/api/clients/register", limiter.middleware(handler.RegisterClientHandler)).Methods("POST")
 	router.HandleFunc("/api/accounts", handler.GetAccountsHandler).Methods("GET")
-	router.HandleFunc("/api/download", handler.DownloadCSVHandler).Methods("GET")
+	router.HandleFunc("/api/accounts/{id}", handler.GetAccountHandler).Methods("GET")
+	router.HandleFunc("/api/accounts/{id}/history", handler.GetAccountHistoryHandler).Methods("GET")
+	router.HandleFunc("/api/countries", handler.GetCountriesHandler).Methods("GET")
+	router.HandleFunc("/api/stats/countries", handler.GetCountryStatsHandler).Methods("GET")
+	router.HandleFunc("/api/stats/timeseries", handler.GetTimeSeriesHandler).Methods("GET")
+	router.HandleFunc("/api/events", handler.GetEventsHandler).Methods("GET")
+	router.HandleFunc("/api/download", handler.DownloadHandler).Methods("GET")
+	router.HandleFunc("/api/posts", handler.GetPostsHandler).Methods("GET")
+	router.HandleFunc("/api/posts/download", handler.DownloadPostsHandler).Methods("GET")
 
 	// Serve static files
 	staticFiles := http.FileServer(http.Dir("static"))
@@ -26,7 +47,7 @@ func New(handler *api.Handler) http.Handler {
 	router.PathPrefix("/").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// First check if it's an API route
 		if strings.HasPrefix(r.URL.Path, "/api/") {
-			http.NotFound(w, r)
+			api.WriteProblem(w, r, http.StatusNotFound, "not_found", "", "Endpoint not found")
 			return
 		}
 
@@ -56,8 +77,21 @@ func New(handler *api.Handler) http.Handler {
 			"http://localhost:8080",
 		},
 		AllowedMethods: []string{"GET", "POST", "OPTIONS"},
-		AllowedHeaders: []string{"Content-Type"},
+		AllowedHeaders: []string{"Content-Type", "X-Client-ID", "X-Signature", "X-Signature-Timestamp", "X-Challenge", "X-Challenge-Solution"},
+		ExposedHeaders: []string{api.RequestIDHeader, "Retry-After", "Deprecation", "Sunset", "Link"},
 	})
 
-	return c.Handler(router)
+	return c.Handler(requestIDs(router))
+}
+
+// requestIDs assigns each request a new ID, passed to handlers in the request
+// ID header and returned to clients in the same header. IDs sent by clients
+// are replaced so that handlers can trust them.
+func requestIDs(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := uuid.NewString()
+		r.Header.Set(api.RequestIDHeader, id)
+		w.Header().Set(api.RequestIDHeader, id)
+		next.ServeHTTP(w, r)
+	})
 }
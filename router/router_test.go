@@ -1,6 +1,7 @@
 package router
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -8,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/takedown-observer/backend/api"
+	"github.com/takedown-observer/backend/models"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
@@ -50,6 +52,12 @@ func TestRouter(t *testing.T) {
 			path:           "/invalid",
 			expectedStatus: http.StatusNotFound,
 		},
+		{
+			name:           "GET metrics is not served publicly",
+			method:         "GET",
+			path:           "/debug/vars",
+			expectedStatus: http.StatusNotFound,
+		},
 		{
 			name:           "GET invalid API path",
 			method:         "GET",
@@ -98,6 +106,10 @@ func TestRouter(t *testing.T) {
 				t.Errorf("Expected status code %d, got %d", tt.expectedStatus, w.Code)
 			}
 
+			if w.Header().Get(api.RequestIDHeader) == "" {
+				t.Errorf("Expected a request ID header")
+			}
+
 			if tt.method == "OPTIONS" {
 				cors := w.Header().Get("Access-Control-Allow-Origin")
 				if cors != "https://twitter.com" {
@@ -107,3 +119,25 @@ func TestRouter(t *testing.T) {
 		})
 	}
 }
+
+func TestRouterAPINotFound(t *testing.T) {
+	router := New(setupTestHandler(t))
+
+	req := httptest.NewRequest("GET", "/api/invalid", nil)
+	req.Header.Set(api.RequestIDHeader, "client-chosen")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var problem models.Problem
+	if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+		t.Fatalf("Failed to decode problem: %v", err)
+	}
+
+	requestID := w.Header().Get(api.RequestIDHeader)
+	if requestID == "client-chosen" || problem.RequestID != requestID {
+		t.Errorf("Expected the problem to carry the assigned request ID %q, got %q", requestID, problem.RequestID)
+	}
+	if problem.Status != http.StatusNotFound || problem.Code != "not_found" {
+		t.Errorf("Unexpected problem %+v", problem)
+	}
+}
@@ -0,0 +1,77 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Validation error codes
+const (
+	// CodeRequired is used for empty values that must be set
+	CodeRequired = "required"
+	// CodeTooLong is used for values exceeding their maximum length
+	CodeTooLong = "too_long"
+	// CodeTooMany is used for lists exceeding their maximum size
+	CodeTooMany = "too_many"
+	// CodeInvalidFormat is used for values that cannot be parsed or contain
+	// invalid characters
+	CodeInvalidFormat = "invalid_format"
+	// CodeDuplicate is used for repeated list entries
+	CodeDuplicate = "duplicate"
+	// CodeNotAllowed is used for values outside a fixed set
+	CodeNotAllowed = "not_allowed"
+	// CodeOutOfRange is used for numbers outside their bounds
+	CodeOutOfRange = "out_of_range"
+	// CodeInvalidRange is used for time ranges that end before they start
+	CodeInvalidRange = "invalid_range"
+)
+
+// Error is a validation failure. Field names the request field or query
+// parameter that failed, if any, and Code is one of the codes above or a
+// caller-defined code.
+type Error struct {
+	Field   string
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Errorf returns a validation error with a formatted message
+func Errorf(field, code, format string, args ...any) *Error {
+	return &Error{Field: field, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// WithField returns a copy of a validation error naming another field, for
+// callers that know the name a value has in the request. Other errors are
+// returned unchanged.
+func WithField(err error, field string) error {
+	var validationErr *Error
+	if !errors.As(err, &validationErr) {
+		return err
+	}
+
+	scoped := *validationErr
+	scoped.Field = field
+	return &scoped
+}
+
+// Nest returns a copy of a validation error with its field nested under a
+// parent, so that "id" nested under "account" becomes "account.id". Other
+// errors are returned unchanged.
+func Nest(err error, parent string) error {
+	var validationErr *Error
+	if !errors.As(err, &validationErr) {
+		return err
+	}
+
+	nested := *validationErr
+	if nested.Field == "" {
+		nested.Field = parent
+	} else {
+		nested.Field = parent + "." + nested.Field
+	}
+	return &nested
+}
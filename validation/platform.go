@@ -0,0 +1,246 @@
+package validation
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Supported platforms
+const (
+	PlatformX        = "x"
+	PlatformYouTube  = "youtube"
+	PlatformTikTok   = "tiktok"
+	PlatformTelegram = "telegram"
+	PlatformMastodon = "mastodon"
+)
+
+// DefaultPlatform is assumed for reports that do not name a platform
+const DefaultPlatform = PlatformX
+
+// Maximum length of post URLs
+const MaxPostURLLength = 2048
+
+// PlatformRules describes the account IDs and names, and the post IDs and
+// URLs, of a platform. Post URLs must be on one of PostHosts, or on any host
+// if there are none.
+type PlatformRules struct {
+	MaxIDLength     int
+	IDPattern       *regexp.Regexp
+	MaxNameLength   int
+	NamePattern     *regexp.Regexp
+	MaxPostIDLength int
+	PostIDPattern   *regexp.Regexp
+	PostHosts       []string
+}
+
+// ValidateID validates the format of an account ID on the platform
+func (r PlatformRules) ValidateID(id string) error {
+	return validateField("id", "account ID", id, r.MaxIDLength, r.IDPattern)
+}
+
+// ValidateName validates the format of an account name on the platform
+func (r PlatformRules) ValidateName(name string) error {
+	return validateField("name", "account name", name, r.MaxNameLength, r.NamePattern)
+}
+
+// ValidatePostID validates the format of a post ID on the platform
+func (r PlatformRules) ValidatePostID(id string) error {
+	return validateField("id", "post ID", id, r.MaxPostIDLength, r.PostIDPattern)
+}
+
+// ValidatePostURL validates that a post URL is an HTTPS URL on one of the
+// platform's hosts
+func (r PlatformRules) ValidatePostURL(postURL string) error {
+	if len(postURL) > MaxPostURLLength {
+		return Errorf("url", CodeTooLong, "post URL exceeds maximum length of %d characters", MaxPostURLLength)
+	}
+
+	parsed, err := url.Parse(postURL)
+	if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+		return Errorf("url", CodeInvalidFormat, "post URL must be an HTTPS URL")
+	}
+
+	if len(r.PostHosts) > 0 && !slices.Contains(r.PostHosts, strings.ToLower(parsed.Hostname())) {
+		return Errorf("url", CodeNotAllowed, "post URL is not on the platform")
+	}
+
+	return nil
+}
+
+// NormalizePostURL returns a validated post URL in a canonical form. The
+// host is lowercased, credentials and fragments are dropped and the path and
+// query are re-encoded, so stored URLs carry no characters that need
+// escaping. Empty URLs are returned unchanged.
+func NormalizePostURL(postURL string) string {
+	parsed, err := url.Parse(postURL)
+	if err != nil || postURL == "" {
+		return ""
+	}
+
+	parsed.User = nil
+	parsed.Host = strings.ToLower(parsed.Host)
+	parsed.Fragment, parsed.RawFragment = "", ""
+	parsed.RawQuery = parsed.Query().Encode()
+	return parsed.String()
+}
+
+func validateField(field, label, value string, maxLength int, pattern *regexp.Regexp) error {
+	if value == "" {
+		return Errorf(field, CodeRequired, "%s cannot be empty", label)
+	}
+
+	if utf8.RuneCountInString(value) > maxLength {
+		return Errorf(field, CodeTooLong, "%s exceeds maximum length of %d characters", label, maxLength)
+	}
+
+	if !pattern.MatchString(value) {
+		return Errorf(field, CodeInvalidFormat, "%s contains invalid characters", label)
+	}
+
+	return nil
+}
+
+var (
+	platformsMu sync.RWMutex
+	platforms   = map[string]PlatformRules{
+		// Numeric user IDs (or legacy alphanumeric IDs) and handles, and
+		// numeric post IDs
+		PlatformX: {
+			MaxIDLength:     MaxAccountIDLength,
+			IDPattern:       regexp.MustCompile("^[a-zA-Z0-9_=]+$"),
+			MaxNameLength:   MaxAccountNameLength,
+			NamePattern:     regexp.MustCompile("^[a-zA-Z0-9_]+$"),
+			MaxPostIDLength: 20,
+			PostIDPattern:   regexp.MustCompile("^[0-9]+$"),
+			PostHosts:       []string{"x.com", "twitter.com", "mobile.x.com", "mobile.twitter.com"},
+		},
+		// Channel IDs and @handles, and video IDs
+		PlatformYouTube: {
+			MaxIDLength:     24,
+			IDPattern:       regexp.MustCompile("^UC[a-zA-Z0-9_-]{22}$"),
+			MaxNameLength:   30,
+			NamePattern:     regexp.MustCompile("^[a-zA-Z0-9._-]+$"),
+			MaxPostIDLength: 11,
+			PostIDPattern:   regexp.MustCompile("^[a-zA-Z0-9_-]{11}$"),
+			PostHosts:       []string{"youtube.com", "www.youtube.com", "m.youtube.com", "youtu.be"},
+		},
+		// Numeric user IDs and usernames, and numeric video IDs
+		PlatformTikTok: {
+			MaxIDLength:     20,
+			IDPattern:       regexp.MustCompile("^[0-9]+$"),
+			MaxNameLength:   24,
+			NamePattern:     regexp.MustCompile("^[a-zA-Z0-9_.]+$"),
+			MaxPostIDLength: 20,
+			PostIDPattern:   regexp.MustCompile("^[0-9]+$"),
+			PostHosts:       []string{"tiktok.com", "www.tiktok.com", "m.tiktok.com"},
+		},
+		// Numeric channel IDs, negative for channels and groups, and
+		// public usernames. Message IDs are numbered per channel, so posts
+		// are identified by channel and message ID.
+		PlatformTelegram: {
+			MaxIDLength:     20,
+			IDPattern:       regexp.MustCompile("^-?[0-9]+$"),
+			MaxNameLength:   32,
+			NamePattern:     regexp.MustCompile("^[a-zA-Z0-9_]+$"),
+			MaxPostIDLength: 41,
+			PostIDPattern:   regexp.MustCompile("^-?[0-9]+/[0-9]+$"),
+			PostHosts:       []string{"t.me"},
+		},
+		// Fully qualified user@instance addresses, which are unique across
+		// instances, and usernames. Status IDs are only unique within an
+		// instance, so posts are identified by instance and status ID, and
+		// their URLs may be on any instance.
+		PlatformMastodon: {
+			MaxIDLength:     100,
+			IDPattern:       regexp.MustCompile(`^[a-zA-Z0-9_]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`),
+			MaxNameLength:   30,
+			NamePattern:     regexp.MustCompile("^[a-zA-Z0-9_]+$"),
+			MaxPostIDLength: 100,
+			PostIDPattern:   regexp.MustCompile(`^[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}/[0-9]+$`),
+		},
+	}
+)
+
+// RegisterPlatform adds a platform or replaces the rules of a platform
+func RegisterPlatform(name string, rules PlatformRules) {
+	platformsMu.Lock()
+	defer platformsMu.Unlock()
+
+	platforms[name] = rules
+}
+
+// LookupPlatform returns the rules of a platform
+func LookupPlatform(name string) (PlatformRules, bool) {
+	platformsMu.RLock()
+	defer platformsMu.RUnlock()
+
+	rules, ok := platforms[name]
+	return rules, ok
+}
+
+// Platforms returns the names of all registered platforms in sorted order
+func Platforms() []string {
+	platformsMu.RLock()
+	defer platformsMu.RUnlock()
+
+	names := make([]string, 0, len(platforms))
+	for name := range platforms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidatePlatform checks that a platform is registered
+func ValidatePlatform(name string) error {
+	if _, ok := LookupPlatform(name); !ok {
+		return ValidateOneOf("platform", name, Platforms()...)
+	}
+	return nil
+}
+
+// ValidatePlatformAccount validates the ID and name of an account on a
+// platform
+func ValidatePlatformAccount(platform, id, name string) error {
+	rules, ok := LookupPlatform(platform)
+	if !ok {
+		return ValidatePlatform(platform)
+	}
+
+	if err := rules.ValidateID(id); err != nil {
+		return err
+	}
+	return rules.ValidateName(name)
+}
+
+// ValidatePlatformPost validates the ID, author and URL of a post on a
+// platform. The URL is optional.
+func ValidatePlatformPost(platform, id, authorID, postURL string) error {
+	rules, ok := LookupPlatform(platform)
+	if !ok {
+		return ValidatePlatform(platform)
+	}
+
+	if err := rules.ValidatePostID(id); err != nil {
+		return err
+	}
+
+	if err := rules.ValidateID(authorID); err != nil {
+		var authorErr *Error
+		if errors.As(err, &authorErr) {
+			return Errorf("author_id", authorErr.Code, "author %s", authorErr.Message)
+		}
+		return err
+	}
+
+	if postURL != "" {
+		return rules.ValidatePostURL(postURL)
+	}
+	return nil
+}
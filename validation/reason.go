@@ -0,0 +1,58 @@
+package validation
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Withholding reasons, as shown in the notice on withheld content
+const (
+	// "withheld in response to a legal demand"
+	ReasonLegalDemand = "legal_demand"
+	// "withheld due to local laws"
+	ReasonLocalLaws = "local_laws"
+	// Sensitive media interstitials
+	ReasonSensitiveMedia = "sensitive_media"
+	// Notices not matching any of the above
+	ReasonOther = "other"
+	// Reports from clients that do not report reasons
+	ReasonUnspecified = "unspecified"
+)
+
+// Reasons lists every withholding reason
+var Reasons = []string{ReasonLegalDemand, ReasonLocalLaws, ReasonSensitiveMedia, ReasonOther, ReasonUnspecified}
+
+// Maximum length of notice texts
+const MaxNoticeLength = 1000
+
+// ValidateReason checks that a withholding reason is known
+func ValidateReason(reason string) error {
+	return ValidateOneOf("reason", reason, Reasons...)
+}
+
+// ValidateNotice validates the text of a withholding notice
+func ValidateNotice(notice string) error {
+	if utf8.RuneCountInString(notice) > MaxNoticeLength {
+		return Errorf("notice", CodeTooLong, "notice exceeds maximum length of %d characters", MaxNoticeLength)
+	}
+	return nil
+}
+
+// ParseReasonList parses a comma-separated list of withholding reasons,
+// returning nil when the value is empty
+func ParseReasonList(value string) ([]string, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(value, ",")
+	reasons := make([]string, len(parts))
+	for i, part := range parts {
+		reasons[i] = strings.TrimSpace(part)
+		if err := ValidateReason(reasons[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return reasons, nil
+}
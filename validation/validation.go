@@ -1,11 +1,11 @@
 package validation
 
 import (
-	"fmt"
 	"html"
 	"regexp"
+	"strconv"
 	"strings"
-	"unicode/utf8"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -20,77 +20,55 @@ const (
 
 var countryCodePattern = regexp.MustCompile("^[A-Z]{2}$")
 
+var textHashPattern = regexp.MustCompile("^[0-9a-f]{64}$")
+
 // ValidateUUID checks if a string is a valid UUID
 func ValidateUUID(id string) bool {
 	_, err := uuid.Parse(id)
 	return err == nil
 }
 
-// ValidateAccountID validates the format of an account ID
+// ValidateAccountID validates the format of an X account ID
 func ValidateAccountID(id string) error {
-	if id == "" {
-		return fmt.Errorf("account ID cannot be empty")
-	}
-
-	if utf8.RuneCountInString(id) > MaxAccountIDLength {
-		return fmt.Errorf("account ID exceeds maximum length of %d characters", MaxAccountIDLength)
-	}
-
-	// Check for valid characters (alphanumeric and underscores)
-	matched, _ := regexp.MatchString("^[a-zA-Z0-9_=]+$", id)
-	if !matched {
-		return fmt.Errorf("account ID contains invalid characters")
-	}
-
-	return nil
+	rules, _ := LookupPlatform(PlatformX)
+	return rules.ValidateID(id)
 }
 
-// ValidateAccountName validates the format of an account name
+// ValidateAccountName validates the format of an X account name
 func ValidateAccountName(name string) error {
-	if name == "" {
-		return fmt.Errorf("account name cannot be empty")
-	}
-
-	if utf8.RuneCountInString(name) > MaxAccountNameLength {
-		return fmt.Errorf("account name exceeds maximum length of %d characters", MaxAccountNameLength)
-	}
+	rules, _ := LookupPlatform(PlatformX)
+	return rules.ValidateName(name)
+}
 
-	// Allow letters, numbers, underscores
-	matched, _ := regexp.MatchString("^[a-zA-Z0-9_]+$", name)
-	if !matched {
-		return fmt.Errorf("account name contains invalid characters")
+// ValidateTextHash validates that a text hash is a hex-encoded SHA-256 hash
+func ValidateTextHash(hash string) error {
+	if !textHashPattern.MatchString(hash) {
+		return Errorf("text_hash", CodeInvalidFormat, "text hash must be a lowercase hex-encoded SHA-256 hash")
 	}
-
 	return nil
 }
 
 // ValidateCountries validates a list of country codes
 func ValidateCountries(countries []string) error {
 	if len(countries) == 0 {
-		return fmt.Errorf("countries list cannot be empty")
+		return Errorf("countries", CodeRequired, "countries list cannot be empty")
 	}
 
 	if len(countries) > MaxCountries {
-		return fmt.Errorf("number of countries exceeds maximum of %d", MaxCountries)
+		return Errorf("countries", CodeTooMany, "number of countries exceeds maximum of %d", MaxCountries)
 	}
 
 	// Create a map to check for duplicates
 	seen := make(map[string]bool)
 
 	for _, country := range countries {
-		// Check length
-		if len(country) != CountryCodeLength {
-			return fmt.Errorf("country code '%s' is not 2 characters", country)
-		}
-
-		// Check if it's uppercase letters only using pre-compiled pattern
-		if !countryCodePattern.MatchString(country) {
-			return fmt.Errorf("country code '%s' is not valid", country)
+		if err := ValidateCountryCode(country); err != nil {
+			return WithField(err, "countries")
 		}
 
 		// Check for duplicates
 		if seen[country] {
-			return fmt.Errorf("duplicate country code '%s'", country)
+			return Errorf("countries", CodeDuplicate, "duplicate country code '%s'", country)
 		}
 		seen[country] = true
 	}
@@ -98,6 +76,104 @@ func ValidateCountries(countries []string) error {
 	return nil
 }
 
+// ValidateCountryCode validates a single ISO 3166-1 alpha-2 country code
+func ValidateCountryCode(country string) error {
+	// Check length
+	if len(country) != CountryCodeLength {
+		return Errorf("country", CodeInvalidFormat, "country code '%s' is not 2 characters", country)
+	}
+
+	// Check if it's uppercase letters only using pre-compiled pattern
+	if !countryCodePattern.MatchString(country) {
+		return Errorf("country", CodeInvalidFormat, "country code '%s' is not valid", country)
+	}
+
+	return nil
+}
+
+// ParseCountryList parses a comma-separated list of country codes from the
+// named query parameter. Codes are upper-cased before validation; an empty
+// value yields an empty list.
+func ParseCountryList(name, value string) ([]string, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(value, ",")
+	countries := make([]string, len(parts))
+	for i, part := range parts {
+		countries[i] = strings.ToUpper(strings.TrimSpace(part))
+	}
+
+	if err := ValidateCountries(countries); err != nil {
+		return nil, WithField(err, name)
+	}
+
+	return countries, nil
+}
+
+// ValidateOneOf checks that a parameter value is one of the allowed values
+func ValidateOneOf(name, value string, allowed ...string) error {
+	for _, candidate := range allowed {
+		if value == candidate {
+			return nil
+		}
+	}
+	return Errorf(name, CodeNotAllowed, "%s must be one of: %s", name, strings.Join(allowed, ", "))
+}
+
+// ParseTimestamp parses an RFC 3339 timestamp from the named query parameter
+func ParseTimestamp(name, value string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, Errorf(name, CodeInvalidFormat, "%s: timestamp '%s' is not a valid RFC 3339 time", name, value)
+	}
+	return t, nil
+}
+
+// ParseLimit parses a result limit from a query parameter, returning
+// defaultLimit when the value is empty
+func ParseLimit(value string, defaultLimit, maxLimit int) (int, error) {
+	if value == "" {
+		return defaultLimit, nil
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 {
+		return 0, Errorf("limit", CodeInvalidFormat, "limit '%s' is not a positive integer", value)
+	}
+
+	if limit > maxLimit {
+		return 0, Errorf("limit", CodeOutOfRange, "limit exceeds maximum of %d", maxLimit)
+	}
+
+	return limit, nil
+}
+
+// ParseCount parses a non-negative integer from a query parameter, returning
+// zero when the value is empty
+func ParseCount(name, value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	count, err := strconv.Atoi(value)
+	if err != nil || count < 0 {
+		return 0, Errorf(name, CodeInvalidFormat, "%s '%s' is not a non-negative integer", name, value)
+	}
+
+	return count, nil
+}
+
+// ValidateTimeRange checks that the start of a time range is before its end.
+// Errors name no field, since the range spans two.
+func ValidateTimeRange(start, end time.Time) error {
+	if !start.Before(end) {
+		return Errorf("", CodeInvalidRange, "time range start %s is not before end %s", start.Format(time.RFC3339), end.Format(time.RFC3339))
+	}
+	return nil
+}
+
 // SanitizeString removes control characters and escapes HTML special characters
 func SanitizeString(input string) string {
 	// Remove any control characters and trim spaces